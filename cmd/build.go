@@ -0,0 +1,114 @@
+package cmd
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// returns the directory caching compiled programs of the session in dir
+func buildDir(dir string) string {
+    return filepath.Join(dir, ".build")
+}
+
+// builds the source file at path with toolchain c.
+// Compiled programs are cached in cacheDir/{file}-{key} where key hashes the
+// source and the toolchain's commands, so unchanged sources aren't compiled
+// again. Only the latest build of each file is kept. Headers included by the
+// source aren't part of the key, delete cacheDir to force a rebuild.
+// Interpreted sources, or any source when cacheDir is empty, run from a
+// fresh scratch directory that Close removes.
+func buildProgram(path string, c workspace.Toolchain, cacheDir string) (*program, error) {
+    if c.Compile == "" || cacheDir == "" {
+        return buildScratch(path, c)
+    }
+    key, err := buildKey(path, c)
+    if err != nil {
+        return nil, err
+    }
+    name := filepath.Base(path)
+    dir  := filepath.Join(cacheDir, name + "-" + key)
+    if info, err := os.Stat(dir); err == nil && info.IsDir() {
+        return &program{dir: dir, exec: expandCommand(c.Execute, path, dir), cached: true}, nil
+    }
+
+    // compile into a temporary dir renamed into place once it succeeds so
+    // failed or interrupted builds are never served from the cache
+    if err := os.MkdirAll(cacheDir, 0755); err != nil {
+        return nil, err
+    }
+    tmp, err := os.MkdirTemp(cacheDir, "tmp-")
+    if err != nil {
+        return nil, err
+    }
+    if err := compile(path, c, tmp); err != nil {
+        os.RemoveAll(tmp)
+        return nil, err
+    }
+    if err := os.Rename(tmp, dir); err != nil {
+        // a concurrent build of the same source got there first
+        os.RemoveAll(tmp)
+        if _, statErr := os.Stat(dir); statErr != nil {
+            return nil, err
+        }
+    }
+    pruneBuilds(cacheDir, name, dir)
+    return &program{dir: dir, exec: expandCommand(c.Execute, path, dir), cached: true}, nil
+}
+
+// builds the source file at path with toolchain c in a fresh scratch directory
+// so binaries don't litter the contest dir. Close removes the directory.
+func buildScratch(path string, c workspace.Toolchain) (*program, error) {
+    dir, err := os.MkdirTemp("", "forces-")
+    if err != nil {
+        return nil, err
+    }
+    if c.Compile != "" {
+        if err := compile(path, c, dir); err != nil {
+            os.RemoveAll(dir)
+            return nil, err
+        }
+    }
+    return &program{dir: dir, exec: expandCommand(c.Execute, path, dir)}, nil
+}
+
+// runs the compile command of c for the source at path in dir
+func compile(path string, c workspace.Toolchain, dir string) error {
+    out, err := shell(dir, expandCommand(c.Compile, path, dir)).CombinedOutput()
+    if err != nil {
+        return fmt.Errorf("compilation of %s with %s failed: %v\n%s", filepath.Base(path), c.Name, err, out)
+    }
+    return nil
+}
+
+// hash of the source at path and the commands building and running it
+func buildKey(path string, c workspace.Toolchain) (string, error) {
+    src, err := os.ReadFile(path)
+    if err != nil {
+        return "", err
+    }
+    h := sha256.New()
+    fmt.Fprintf(h, "%s\x00%s\x00", c.Compile, c.Execute)
+    h.Write(src)
+    return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// removes cached builds of the file name other than keep
+func pruneBuilds(cacheDir, name, keep string) {
+    old, _ := filepath.Glob(filepath.Join(cacheDir, name + "-*"))
+    for _, dir := range old {
+        // builds of a file named like "A.cpp-2.cpp" share the prefix
+        key := strings.TrimPrefix(filepath.Base(dir), name + "-")
+        if _, err := hex.DecodeString(key); err != nil || len(key) != 16 {
+            continue
+        }
+        if dir != keep {
+            os.RemoveAll(dir)
+        }
+    }
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+func TestBuildCache(t *testing.T) {
+    src   := t.TempDir()
+    cache := filepath.Join(t.TempDir(), ".build")
+    log   := filepath.Join(t.TempDir(), "compiles")
+    // "compiles" by copying, refusing sources containing "error"
+    c := workspace.Toolchain{
+        Name:    "copy",
+        Compile: "! grep -q error {{source}} && cp {{source}} {{binary}} && echo {{source}} >> " + shellQuote(log),
+        Execute: "cat {{binary}}",
+    }
+    write := func(name, content string) string {
+        path := filepath.Join(src, name)
+        if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+            t.Fatal(err)
+        }
+        return path
+    }
+    compiles := func() int {
+        b, _ := os.ReadFile(log)
+        return strings.Count(string(b), "\n")
+    }
+    build := func(path string, c workspace.Toolchain) *program {
+        t.Helper()
+        p, err := buildProgram(path, c, cache)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !p.cached {
+            t.Errorf("build of %s isn't cached", path)
+        }
+        p.Close()
+        if _, err := os.Stat(filepath.Join(p.dir, "sol")); err != nil {
+            t.Errorf("closing a cached build removed it: %v", err)
+        }
+        return p
+    }
+    builds := func() []string {
+        dirs, _ := filepath.Glob(filepath.Join(cache, "*"))
+        for i, dir := range dirs {
+            dirs[i] = filepath.Base(dir)
+        }
+        return dirs
+    }
+
+    a := write("A.cpp", "a1")
+    first := build(a, c)
+    // cache hit: the same program without compiling again
+    if again := build(a, c); again.dir != first.dir || compiles() != 1 {
+        t.Errorf("rebuilt unchanged source: %s, %s, %d compiles", first.dir, again.dir, compiles())
+    }
+
+    // another file and one sharing the name prefix are cached beside A
+    build(write("B.cpp", "b1"), c)
+    build(write("A.cpp-2.cpp", "a2"), c)
+    if n := len(builds()); n != 3 {
+        t.Errorf("cache holds %v, want a build of each file", builds())
+    }
+
+    // changed source: rebuilt, the old build of A pruned
+    write("A.cpp", "a2")
+    second := build(a, c)
+    if second.dir == first.dir || compiles() != 4 {
+        t.Errorf("changed source not rebuilt: %s, %d compiles", second.dir, compiles())
+    }
+    if _, err := os.Stat(first.dir); !os.IsNotExist(err) {
+        t.Errorf("old build of A kept: %v", err)
+    }
+    if n := len(builds()); n != 3 {
+        t.Errorf("pruning A removed builds of other files: %v", builds())
+    }
+
+    // changed commands: rebuilt
+    changed := c
+    changed.Execute = "cat {{binary}} {{binary}}"
+    if third := build(a, changed); third.dir == second.dir || compiles() != 5 {
+        t.Errorf("changed command not rebuilt: %s, %d compiles", third.dir, compiles())
+    }
+
+    // failed builds leave nothing behind and are tried again
+    write("A.cpp", "error")
+    before := builds()
+    for i := 0; i < 2; i++ {
+        if _, err := buildProgram(a, c, cache); err == nil {
+            t.Fatal("build of a broken source succeeded")
+        }
+    }
+    if after := builds(); strings.Join(after, " ") != strings.Join(before, " ") {
+        t.Errorf("failed build changed the cache from %v to %v", before, after)
+    }
+}
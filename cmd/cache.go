@@ -0,0 +1,59 @@
+package cmd
+
+import (
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/spf13/cobra"
+)
+
+// forces cache list
+// forces cache clear
+var cacheCmd = &cobra.Command{
+    Use: "cache",
+    Short: "Manage the cache of downloaded pages",
+}
+
+var cacheListCmd = &cobra.Command{
+    Use: "list",
+    Short: "List cached pages",
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        c := openWorkspace().Cache
+        entries, err := c.List()
+        if err != nil {
+            log.Fatal(err)
+        }
+        var total int64
+        for _, e := range entries {
+            status := "fresh"
+            if !c.Fresh(e) {
+                status = "stale"
+            }
+            age := time.Since(e.Fetched).Round(time.Second)
+            fmt.Printf("%-5s %8s %6dKB  %s\n", status, age, e.Size>>10, e.Url)
+            total += e.Size
+        }
+        fmt.Printf("%d pages, %dKB in %s\n", len(entries), total>>10, c.Dir)
+    },
+}
+
+var cacheClearCmd = &cobra.Command{
+    Use: "clear",
+    Short: "Remove every cached page",
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        c := openWorkspace().Cache
+        if err := c.Clear(); err != nil {
+            log.Fatal(err)
+        }
+        fmt.Printf("cleared %s\n", c.Dir)
+    },
+}
+
+func init() {
+    cacheCmd.AddCommand(cacheListCmd)
+    cacheCmd.AddCommand(cacheClearCmd)
+    rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// testlib checker exit codes
+const (
+    checkerOK     = 0
+    checkerWA     = 1
+    checkerPE     = 2
+    checkerFail   = 3
+    checkerEOF    = 8 // read past the end of the contestant's output
+)
+
+// special judge compiled from a checker source registered for a problem
+// invoked testlib-style as: checker <input> <output> <answer>
+type programChecker struct {
+    *program
+}
+
+// compiles the checker source at path with toolchain c, cached in cacheDir
+func newProgramChecker(path string, c workspace.Toolchain, cacheDir string) (*programChecker, error) {
+    p, err := buildProgram(path, c, cacheDir)
+    if err != nil {
+        return nil, err
+    }
+    return &programChecker{p}, nil
+}
+
+func (c *programChecker) Check(test workspace.Test, actual string) (workspace.SVLabel, string, error) {
+    // checker reads input, contestant output and jury answer from files
+    dir, err := os.MkdirTemp(c.dir, "check-")
+    if err != nil {
+        return workspace.NA, "", err
+    }
+    defer os.RemoveAll(dir)
+    files := map[string]string{"input.txt": test.Input, "output.txt": actual, "answer.txt": test.Output}
+    for name, content := range files {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+            return workspace.NA, "", err
+        }
+    }
+
+    var out bytes.Buffer
+    cmd := c.command(
+        filepath.Join(dir, "input.txt"),
+        filepath.Join(dir, "output.txt"),
+        filepath.Join(dir, "answer.txt"),
+    )
+    cmd.Stdout = &out
+    cmd.Stderr = &out
+    err = cmd.Run()
+    msg := strings.TrimSpace(out.String())
+
+    code := checkerOK
+    var exitErr *exec.ExitError
+    if errors.As(err, &exitErr) {
+        code = exitErr.ExitCode()
+    } else if err != nil {
+        return workspace.NA, "", err
+    }
+    switch code {
+    case checkerOK:
+        return workspace.Accepted, msg, nil
+    case checkerWA, checkerPE:
+        return workspace.WrongAnswer, msg, nil
+    case checkerFail:
+        return workspace.DenialOfJudgement, "checker failed: " + msg, nil
+    }
+    return workspace.DenialOfJudgement, fmt.Sprintf("checker exited with code %d: %s", code, msg), nil
+}
+
+// copies the checker source at src into dir/tests/{problemId}/checker{ext}
+// returns the file name of the copy
+func registerChecker(dir string, p workspace.ProblemState, src string) (string, error) {
+    in, err := os.Open(src)
+    if err != nil {
+        return "", err
+    }
+    defer in.Close()
+
+    name := "checker" + filepath.Ext(src)
+    out, err := os.Create(filepath.Join(dir, "tests", p.Id(), name))
+    if err != nil {
+        return "", err
+    }
+    if _, err := io.Copy(out, in); err != nil {
+        out.Close()
+        return "", err
+    }
+    return name, out.Close()
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// checker program running script with sh, called as: script <input> <output> <answer>
+func scriptChecker(t *testing.T, script string) *programChecker {
+    dir := t.TempDir()
+    if err := os.WriteFile(filepath.Join(dir, "check.sh"), []byte(script), 0755); err != nil {
+        t.Fatal(err)
+    }
+    return &programChecker{&program{dir: dir, exec: "sh ./check.sh"}}
+}
+
+func TestProgramChecker(t *testing.T) {
+    cases := []struct {
+        name   string
+        script string
+        label  workspace.SVLabel
+        msg    string
+    }{
+        {"ok", "echo 'ok 1 number' >&2; exit 0", workspace.Accepted, "ok 1 number"},
+        {"wrong answer", "echo 'wrong answer expected 3, found 4' >&2; exit 1", workspace.WrongAnswer, "wrong answer expected 3, found 4"},
+        {"presentation error", "echo 'wrong output format' >&2; exit 2", workspace.WrongAnswer, "wrong output format"},
+        {"checker failure", "echo 'answer is invalid' >&2; exit 3", workspace.DenialOfJudgement, "checker failed: answer is invalid"},
+        {"unknown code", "echo crashed >&2; exit 7", workspace.DenialOfJudgement, "checker exited with code 7: crashed"},
+        {"stdout", "echo 'ok on stdout'", workspace.Accepted, "ok on stdout"},
+        // the checker gets input, contestant output and jury answer in that order
+        {"arguments", `[ "$(cat "$1")" = 1 ] && [ "$(cat "$2")" = 2 ] && [ "$(cat "$3")" = 3 ] || exit 1`, workspace.Accepted, ""},
+        {"compares files", `cmp -s "$2" "$3"`, workspace.WrongAnswer, ""},
+    }
+    for _, c := range cases {
+        checker := scriptChecker(t, c.script)
+        label, msg, err := checker.Check(workspace.Test{Input: "1", Output: "3"}, "2")
+        if err != nil {
+            t.Errorf("%s: %v", c.name, err)
+            continue
+        }
+        if label != c.label || msg != c.msg {
+            t.Errorf("%s: %v %q, want %v %q", c.name, label, msg, c.label, c.msg)
+        }
+    }
+}
+
+func TestProgramCheckerCleansUp(t *testing.T) {
+    checker := scriptChecker(t, "exit 0")
+    if _, _, err := checker.Check(workspace.Test{Input: "1", Output: "1"}, "1"); err != nil {
+        t.Fatal(err)
+    }
+    entries, err := os.ReadDir(checker.dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, e := range entries {
+        if strings.HasPrefix(e.Name(), "check-") {
+            t.Errorf("left %s behind", e.Name())
+        }
+    }
+}
@@ -2,18 +2,63 @@ package cmd
 
 import (
     "fmt"
+    "log"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+
     "github.com/spf13/cobra"
 )
 
+// forces code A
+// forces code   <- opens most recently modified solution
 var codeCmd = &cobra.Command{
-    Use: "code",
-    Short: "",
+    Use: "code [problem]",
+    Short: "Open a solution in $EDITOR at its template cursor",
+    Args: cobra.MaximumNArgs(1),
     Run: func(cmd *cobra.Command, args []string) {
-        fmt.Println("hello from Cobra!")
+        w := openWorkspace()
+        session, err := w.ReadSession()
+        if err != nil {
+            log.Fatal(err)
+        }
+        problem, err := resolveProblem(session, args)
+        if err != nil {
+            log.Fatal(err)
+        }
+        editor := exec.Command("sh", "-c", editorCommand(filepath.Join(session.Path, problem.FileName), problem.Cursor))
+        editor.Stdin  = os.Stdin
+        editor.Stdout = os.Stdout
+        editor.Stderr = os.Stderr
+        if err := editor.Run(); err != nil {
+            log.Fatal(err)
+        }
     },
 }
 
+// returns the shell command opening path in $VISUAL or $EDITOR (vi if
+// neither is set) with the cursor on line, if known. Most terminal editors
+// take +line, VS Code and friends take -g path:line.
+func editorCommand(path string, line int) string {
+    editor := os.Getenv("VISUAL")
+    if editor == "" {
+        editor = os.Getenv("EDITOR")
+    }
+    if editor == "" {
+        editor = "vi"
+    }
+    if line == 0 {
+        return editor + " " + shellQuote(path)
+    }
+    fields := strings.Fields(editor)
+    switch filepath.Base(fields[0]) {
+    case "code", "codium", "code-insiders":
+        return fmt.Sprintf("%s -g %s", editor, shellQuote(fmt.Sprintf("%s:%d", path, line)))
+    }
+    return fmt.Sprintf("%s +%d %s", editor, line, shellQuote(path))
+}
+
 func init() {
     rootCmd.AddCommand(codeCmd)
 }
-
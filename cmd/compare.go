@@ -0,0 +1,162 @@
+package cmd
+
+import (
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// Comparator decides whether a solution's output matches the expected output
+// Compare returns nil on a match, otherwise an error describing the first mismatch
+type Comparator interface {
+    Compare(expected, actual string) error
+}
+
+// Checker judges a solution's output for a sample test
+// msg explains a rejected output, err reports a failure to judge at all
+type Checker interface {
+    Check(test workspace.Test, actual string) (label workspace.SVLabel, msg string, err error)
+}
+
+// adapts a Comparator to the Checker interface
+type comparatorChecker struct {
+    Comparator
+}
+
+func (c comparatorChecker) Check(test workspace.Test, actual string) (workspace.SVLabel, string, error) {
+    if err := c.Compare(test.Output, actual); err != nil {
+        return workspace.WrongAnswer, err.Error(), nil
+    }
+    return workspace.Accepted, "", nil
+}
+
+// checker used when a problem doesn't configure one
+const defaultChecker = "exact"
+
+// checker specs accepted by parseComparator (and --checker)
+//   exact        byte equality ignoring trailing whitespace
+//   lines        line by line, ignoring leading/trailing whitespace of each line
+//   tokens       whitespace separated tokens
+//   yesno        tokens, case-insensitive (YES/yes/Yes)
+//   float[:eps]  tokens, numbers within absolute or relative error eps
+//   abs[:eps]    tokens, numbers within absolute error eps
+//   rel[:eps]    tokens, numbers within relative error eps
+func parseComparator(spec string) (Comparator, error) {
+    if spec == "" {
+        spec = defaultChecker
+    }
+    name, arg, hasArg := strings.Cut(spec, ":")
+    eps := 1e-6
+    if hasArg {
+        e, err := strconv.ParseFloat(arg, 64)
+        if err != nil || e < 0 {
+            return nil, fmt.Errorf("invalid epsilon %q in checker %q", arg, spec)
+        }
+        eps = e
+    }
+    switch name {
+    case "exact":
+        return exactComparator{}, nil
+    case "lines":
+        return lineComparator{}, nil
+    case "tokens":
+        return tokenComparator{}, nil
+    case "yesno":
+        return tokenComparator{ignoreCase: true}, nil
+    case "float":
+        return floatComparator{abs: eps, rel: eps}, nil
+    case "abs":
+        return floatComparator{abs: eps}, nil
+    case "rel":
+        return floatComparator{rel: eps}, nil
+    }
+    return nil, fmt.Errorf("unknown checker %q", spec)
+}
+
+type exactComparator struct{}
+
+func (exactComparator) Compare(expected, actual string) error {
+    if sameOutput(expected, actual) {
+        return nil
+    }
+    e := strings.Split(normalize(expected), "\n")
+    a := strings.Split(normalize(actual), "\n")
+    return lineMismatch(e, a, func(x, y string) bool { return x == y })
+}
+
+type lineComparator struct{}
+
+func (lineComparator) Compare(expected, actual string) error {
+    e := strings.Split(normalize(expected), "\n")
+    a := strings.Split(normalize(actual), "\n")
+    return lineMismatch(e, a, func(x, y string) bool {
+        return strings.TrimSpace(x) == strings.TrimSpace(y)
+    })
+}
+
+// returns an error for the first line where eq fails or the line counts differ
+func lineMismatch(expected, actual []string, eq func(string, string) bool) error {
+    for i := 0; i < len(expected) && i < len(actual); i++ {
+        if !eq(expected[i], actual[i]) {
+            return fmt.Errorf("line %d: expected %q, found %q", i+1, expected[i], actual[i])
+        }
+    }
+    if len(expected) != len(actual) {
+        return fmt.Errorf("expected %d lines, found %d", len(expected), len(actual))
+    }
+    return nil
+}
+
+type tokenComparator struct {
+    ignoreCase bool
+}
+
+func (c tokenComparator) Compare(expected, actual string) error {
+    return tokenMismatch(strings.Fields(expected), strings.Fields(actual), func(x, y string) error {
+        if x == y || (c.ignoreCase && strings.EqualFold(x, y)) {
+            return nil
+        }
+        return fmt.Errorf("expected %q, found %q", x, y)
+    })
+}
+
+// a token pair matches if both parse as numbers within either error bound
+// non-numeric tokens must be equal
+type floatComparator struct {
+    abs float64
+    rel float64
+}
+
+func (c floatComparator) Compare(expected, actual string) error {
+    return tokenMismatch(strings.Fields(expected), strings.Fields(actual), func(x, y string) error {
+        if x == y {
+            return nil
+        }
+        want, err1 := strconv.ParseFloat(x, 64)
+        got,  err2 := strconv.ParseFloat(y, 64)
+        if err1 != nil || err2 != nil {
+            return fmt.Errorf("expected %q, found %q", x, y)
+        }
+        diff := math.Abs(want - got)
+        if diff <= c.abs || diff <= c.rel*math.Abs(want) {
+            return nil
+        }
+        return fmt.Errorf("expected %s, found %s (error %g)", x, y, diff)
+    })
+}
+
+// returns an error for the first token pair rejected by match or a token count mismatch
+func tokenMismatch(expected, actual []string, match func(string, string) error) error {
+    for i := 0; i < len(expected) && i < len(actual); i++ {
+        if err := match(expected[i], actual[i]); err != nil {
+            return fmt.Errorf("token %d: %v", i+1, err)
+        }
+    }
+    if len(expected) != len(actual) {
+        return fmt.Errorf("expected %d tokens, found %d", len(expected), len(actual))
+    }
+    return nil
+}
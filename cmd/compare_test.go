@@ -0,0 +1,134 @@
+package cmd
+
+import (
+    "testing"
+)
+
+func TestParseComparator(t *testing.T) {
+    cases := []struct {
+        spec string
+        want Comparator
+    }{
+        {"", exactComparator{}},
+        {"exact", exactComparator{}},
+        {"lines", lineComparator{}},
+        {"tokens", tokenComparator{}},
+        {"yesno", tokenComparator{ignoreCase: true}},
+        {"float", floatComparator{abs: 1e-6, rel: 1e-6}},
+        {"float:1e-9", floatComparator{abs: 1e-9, rel: 1e-9}},
+        {"abs:0.5", floatComparator{abs: 0.5}},
+        {"rel:0", floatComparator{}},
+    }
+    for _, c := range cases {
+        got, err := parseComparator(c.spec)
+        if err != nil {
+            t.Errorf("parseComparator(%q): %v", c.spec, err)
+            continue
+        }
+        if got != c.want {
+            t.Errorf("parseComparator(%q) = %#v, want %#v", c.spec, got, c.want)
+        }
+    }
+}
+
+func TestParseComparatorInvalid(t *testing.T) {
+    specs := []string{"fuzzy", "EXACT", "float:", "float:abc", "abs:-1", "rel:1e-6:2", "tokens :1"}
+    for _, spec := range specs {
+        if cmp, err := parseComparator(spec); err == nil {
+            t.Errorf("parseComparator(%q) = %#v, want error", spec, cmp)
+        }
+    }
+}
+
+func TestCompare(t *testing.T) {
+    cases := []struct {
+        spec     string
+        expected string
+        actual   string
+        match    bool
+    }{
+        // exact ignores trailing whitespace on lines and trailing blank lines only
+        {"exact", "1 2\n3\n", "1 2\n3\n", true},
+        {"exact", "1 2\n3\n", "1 2  \n3\t\n\n\n", true},
+        {"exact", "1 2\n3\n", "1 2\n3", true},
+        {"exact", "1 2\n3\n", "1 2\r\n3\r\n", true},
+        {"exact", "1 2\n3\n", " 1 2\n3\n", false},
+        {"exact", "1 2\n3\n", "1  2\n3\n", false},
+        {"exact", "1\n\n2\n", "1\n2\n", false},
+        {"exact", "", "\n\n", true},
+        {"exact", "1\n", "", false},
+
+        // lines also ignores leading whitespace but not line structure
+        {"lines", "1 2\n3\n", "  1 2\n3  \n", true},
+        {"lines", "1 2\n3\n", "1 2 3\n", false},
+        {"lines", "1 2\n", "1  2\n", false},
+
+        // tokens only compares whitespace separated words
+        {"tokens", "1 2\n3\n", "1\n2 3", true},
+        {"tokens", "1 2 3", "1 2", false},
+        {"tokens", "YES", "yes", false},
+        {"tokens", "", "  \n ", true},
+
+        {"yesno", "YES\nNO\n", "yes no", true},
+        {"yesno", "YES", "Yes", true},
+        {"yesno", "YES", "NO", false},
+
+        // float accepts absolute or relative error within eps
+        {"float", "1.0", "1.0000009", true},
+        {"float", "1.0", "1.0000011", false},
+        {"float", "1000000", "1000000.9", true},
+        {"float", "1000000", "1000002", false},
+        {"float", "0", "1e-7", true},
+        {"float", "0.5 x", "0.5000001 x", true},
+        {"float", "0.5 x", "0.5 y", false},
+        {"float", "1", "nan", false},
+        {"float", "1 2", "1", false},
+
+        // epsilon boundaries are inclusive
+        {"abs:0.5", "1", "1.5", true},
+        {"abs:0.5", "1", "1.5000001", false},
+        {"abs:0.5", "1000", "1000.6", false},
+        {"rel:0.5", "1000", "1500", true},
+        {"rel:0.5", "1000", "1500.1", false},
+        {"rel:0.5", "0", "0.1", false},
+        {"abs:0", "0.1", "0.10", true},
+    }
+    for _, c := range cases {
+        cmp, err := parseComparator(c.spec)
+        if err != nil {
+            t.Fatal(err)
+        }
+        err = cmp.Compare(c.expected, c.actual)
+        if c.match && err != nil {
+            t.Errorf("%s: %q vs %q: unexpected mismatch: %v", c.spec, c.expected, c.actual, err)
+        }
+        if !c.match && err == nil {
+            t.Errorf("%s: %q vs %q: expected a mismatch", c.spec, c.expected, c.actual)
+        }
+    }
+}
+
+func TestCompareMessages(t *testing.T) {
+    cases := []struct {
+        spec     string
+        expected string
+        actual   string
+        msg      string
+    }{
+        {"exact", "1\n2\n", "1\n3\n", `line 2: expected "2", found "3"`},
+        {"exact", "1\n2\n", "1\n", "expected 2 lines, found 1"},
+        {"tokens", "1 2", "1 2 3", "expected 2 tokens, found 3"},
+        {"tokens", "1 2", "1 5", `token 2: expected "2", found "5"`},
+        {"abs:0.1", "1.0", "1.5", "token 1: expected 1.0, found 1.5 (error 0.5)"},
+    }
+    for _, c := range cases {
+        cmp, err := parseComparator(c.spec)
+        if err != nil {
+            t.Fatal(err)
+        }
+        err = cmp.Compare(c.expected, c.actual)
+        if err == nil || err.Error() != c.msg {
+            t.Errorf("%s: %q vs %q: got %v, want %q", c.spec, c.expected, c.actual, err, c.msg)
+        }
+    }
+}
@@ -0,0 +1,384 @@
+package cmd
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "unicode/utf8"
+)
+
+// ansi escape codes used by the diff renderer
+const (
+    ansiReset     = "\033[0m"
+    ansiRed       = "\033[31m"
+    ansiGreen     = "\033[32m"
+    ansiDim       = "\033[2m"
+    ansiHighlight = "\033[1;4m"
+)
+
+// limits applied by the diff renderer unless full output is requested
+const (
+    diffMaxInput   = 2000 // lines (or tokens) of each output that are diffed
+    diffMaxShown   = 40   // rendered lines
+    diffMaxWidth   = 160  // runes per rendered line
+    diffContext    = 3    // unchanged lines shown around each change
+    tokenContext   = 5    // unchanged tokens shown around each change
+)
+
+// renders the difference between expected and actual output of a test
+type diffRenderer struct {
+    color bool
+    full  bool // disable truncation
+}
+
+// returns a renderer that colors its output when stdout is a terminal
+// and NO_COLOR isn't set, unless noColor is true
+func newDiffRenderer(noColor, full bool) diffRenderer {
+    color := !noColor && os.Getenv("NO_COLOR") == ""
+    if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+        color = false
+    }
+    return diffRenderer{color, full}
+}
+
+type editKind byte
+const (
+    editEqual  editKind = ' '
+    editDelete editKind = '-' // only in expected
+    editInsert editKind = '+' // only in actual
+)
+
+type edit struct {
+    kind editKind
+    text string
+}
+
+// writes a diff of expected vs actual to w. Token comparators get a token
+// diff, every other comparator (or none, for checker programs) a line diff.
+func (r diffRenderer) render(w io.Writer, expected, actual string, cmp Comparator) {
+    switch cmp.(type) {
+    case tokenComparator, floatComparator:
+        r.renderTokens(w, strings.Fields(expected), strings.Fields(actual))
+    default:
+        r.renderLines(w, strings.Split(normalize(expected), "\n"), strings.Split(normalize(actual), "\n"))
+    }
+}
+
+// unified diff of lines, "-" expected and "+" actual, with the first
+// differing token of the first changed line pair highlighted
+func (r diffRenderer) renderLines(w io.Writer, expected, actual []string) {
+    expected, cutE := r.truncate(expected)
+    actual,   cutA := r.truncate(actual)
+    edits := myersDiff(expected, actual)
+
+    fmt.Fprintf(w, "%s expected %s actual\n", r.paint(ansiRed, "-"), r.paint(ansiGreen, "+"))
+    shown := 0
+    highlighted := false
+    for i, hunk := range hunks(edits, diffContext) {
+        if i > 0 {
+            fmt.Fprintln(w, r.paint(ansiDim, "  ..."))
+        }
+        for j := hunk[0]; j < hunk[1]; j++ {
+            e := edits[j]
+            // highlight the first mismatching token of the first "-" "+" pair
+            pair := !highlighted && e.kind == editDelete && j+1 < len(edits) && edits[j+1].kind == editInsert
+            lines := 1
+            if pair {
+                lines = 2
+            }
+            if !r.full && shown+lines > diffMaxShown {
+                fmt.Fprintln(w, r.paint(ansiDim, "  ... diff truncated, use --full to see everything"))
+                return
+            }
+            text := r.clip(e.text)
+            if pair {
+                a, b := r.highlightMismatch(text, r.clip(edits[j+1].text))
+                fmt.Fprintln(w, r.paint(ansiRed, "- ") + a)
+                fmt.Fprintln(w, r.paint(ansiGreen, "+ ") + b)
+                highlighted = true
+                shown += 2
+                j++
+                continue
+            }
+            switch e.kind {
+            case editDelete:
+                fmt.Fprintln(w, r.paint(ansiRed, "- " + text))
+            case editInsert:
+                fmt.Fprintln(w, r.paint(ansiGreen, "+ " + text))
+            default:
+                fmt.Fprintln(w, "  " + text)
+            }
+            shown++
+        }
+    }
+    if cutE || cutA {
+        fmt.Fprintln(w, r.paint(ansiDim, "  ... output truncated, use --full to see everything"))
+    }
+}
+
+// inline token diff: [-expected-] and {+actual+} around changed tokens
+func (r diffRenderer) renderTokens(w io.Writer, expected, actual []string) {
+    expected, cutE := r.truncate(expected)
+    actual,   cutA := r.truncate(actual)
+    edits := myersDiff(expected, actual)
+
+    words := make([]string, 0)
+render:
+    for i, hunk := range hunks(edits, tokenContext) {
+        if i > 0 {
+            words = append(words, r.paint(ansiDim, "..."))
+        }
+        for _, e := range edits[hunk[0]:hunk[1]] {
+            if !r.full && len(words) >= diffMaxShown*8 {
+                words = append(words, r.paint(ansiDim, "... diff truncated, use --full to see everything"))
+                break render
+            }
+            switch e.kind {
+            case editDelete:
+                words = append(words, r.mark(ansiRed, "[-", e.text, "-]"))
+            case editInsert:
+                words = append(words, r.mark(ansiGreen, "{+", e.text, "+}"))
+            default:
+                words = append(words, e.text)
+            }
+        }
+    }
+    if cutE || cutA {
+        words = append(words, r.paint(ansiDim, "... output truncated, use --full to see everything"))
+    }
+
+    // wrap words into lines no wider than diffMaxWidth (ignoring escape codes)
+    line, width := "", 0
+    for _, word := range words {
+        n := utf8.RuneCountInString(stripANSI(word))
+        if width > 0 && width+1+n > diffMaxWidth {
+            fmt.Fprintln(w, line)
+            line, width = "", 0
+        }
+        if width > 0 {
+            line += " "
+            width++
+        }
+        line  += word
+        width += n
+    }
+    if width > 0 {
+        fmt.Fprintln(w, line)
+    }
+}
+
+// splits expected and actual line a, b into tokens and highlights the first
+// position where they differ
+func (r diffRenderer) highlightMismatch(a, b string) (string, string) {
+    ta, tb := strings.Fields(a), strings.Fields(b)
+    i := 0
+    for i < len(ta) && i < len(tb) && ta[i] == tb[i] {
+        i++
+    }
+    mark := func(tokens []string, color string) string {
+        out := make([]string, len(tokens))
+        for j, t := range tokens {
+            if j == i {
+                out[j] = r.mark(ansiHighlight + color, ">", t, "<")
+            } else {
+                out[j] = r.paint(color, t)
+            }
+        }
+        return strings.Join(out, " ")
+    }
+    return mark(ta, ansiRed), mark(tb, ansiGreen)
+}
+
+// wraps s in the escape code, or returns s unchanged without color
+func (r diffRenderer) paint(code, s string) string {
+    if !r.color {
+        return s
+    }
+    return code + s + ansiReset
+}
+
+// colors s when color is enabled, otherwise surrounds it with open/close
+func (r diffRenderer) mark(code, open, s, close string) string {
+    if r.color {
+        return code + s + ansiReset
+    }
+    return open + s + close
+}
+
+// limits the number of diffed lines or tokens unless full is set
+func (r diffRenderer) truncate(s []string) ([]string, bool) {
+    if r.full || len(s) <= diffMaxInput {
+        return s, false
+    }
+    return s[:diffMaxInput], true
+}
+
+// limits the width of a rendered line to diffMaxWidth runes unless full is set
+func (r diffRenderer) clip(s string) string {
+    if r.full || utf8.RuneCountInString(s) <= diffMaxWidth {
+        return s
+    }
+    runes := 0
+    for i := range s {
+        if runes == diffMaxWidth {
+            return s[:i] + "..."
+        }
+        runes++
+    }
+    return s
+}
+
+func stripANSI(s string) string {
+    var b strings.Builder
+    for i := 0; i < len(s); i++ {
+        if s[i] == '\033' {
+            for i < len(s) && s[i] != 'm' {
+                i++
+            }
+            continue
+        }
+        b.WriteByte(s[i])
+    }
+    return b.String()
+}
+
+// returns [start, end) index ranges of edits covering every change plus
+// up to context unchanged edits on either side, merging overlapping ranges
+func hunks(edits []edit, context int) [][2]int {
+    ranges := make([][2]int, 0)
+    for i, e := range edits {
+        if e.kind == editEqual {
+            continue
+        }
+        start, end := i-context, i+context+1
+        if start < 0 {
+            start = 0
+        }
+        if end > len(edits) {
+            end = len(edits)
+        }
+        if n := len(ranges); n > 0 && start <= ranges[n-1][1] {
+            ranges[n-1][1] = end
+            continue
+        }
+        ranges = append(ranges, [2]int{start, end})
+    }
+    return ranges
+}
+
+// edit distance beyond which diffs stop searching for the shortest edit
+// script and replace everything between the common prefix and suffix,
+// bounding time by O((n+m)·diffMaxCost) and memory by O(diffMaxCost²)
+const diffMaxCost = 1000
+
+// edit script turning a into b, the shortest unless it costs more than
+// diffMaxCost edits. The common prefix and suffix are always kept so the
+// fallback still starts at the first mismatch.
+func myersDiff(a, b []string) []edit {
+    pre := 0
+    for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+        pre++
+    }
+    suf := 0
+    for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+        suf++
+    }
+
+    edits := make([]edit, 0, len(a)+len(b))
+    for _, s := range a[:pre] {
+        edits = append(edits, edit{editEqual, s})
+    }
+    ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+    if middle, ok := shortestEdit(ma, mb, diffMaxCost); ok {
+        edits = append(edits, middle...)
+    } else {
+        for _, s := range ma {
+            edits = append(edits, edit{editDelete, s})
+        }
+        for _, s := range mb {
+            edits = append(edits, edit{editInsert, s})
+        }
+    }
+    for _, s := range a[len(a)-suf:] {
+        edits = append(edits, edit{editEqual, s})
+    }
+    return edits
+}
+
+// shortest edit script turning a into b (Myers' O(ND) algorithm),
+// !ok if it takes more than maxCost edits
+func shortestEdit(a, b []string, maxCost int) ([]edit, bool) {
+    n, m := len(a), len(b)
+    max := n + m
+    if max > maxCost {
+        max = maxCost
+    }
+    offset := max + 1
+    v := make([]int, 2*max+3)
+    // trace[d] holds v[-d..d] as it was after step d
+    trace := make([][]int, 0)
+
+    found := false
+    for d := 0; d <= max && !found; d++ {
+        for k := -d; k <= d; k += 2 {
+            var x int
+            if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+                x = v[offset+k+1]
+            } else {
+                x = v[offset+k-1] + 1
+            }
+            y := x - k
+            for x < n && y < m && a[x] == b[y] {
+                x++
+                y++
+            }
+            v[offset+k] = x
+            if x >= n && y >= m {
+                found = true
+                break
+            }
+        }
+        trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+    }
+    if !found {
+        return nil, false
+    }
+
+    // walk the trace backwards collecting edits in reverse
+    edits := make([]edit, 0, n+m)
+    x, y := n, m
+    for d := len(trace) - 1; d > 0; d-- {
+        // furthest x on diagonal k after step d-1
+        prev := func(k int) int { return trace[d-1][k+d-1] }
+        k := x - y
+        var prevK int
+        if k == -d || (k != d && prev(k-1) < prev(k+1)) {
+            prevK = k + 1
+        } else {
+            prevK = k - 1
+        }
+        prevX := prev(prevK)
+        prevY := prevX - prevK
+        for x > prevX && y > prevY {
+            edits = append(edits, edit{editEqual, a[x-1]})
+            x--
+            y--
+        }
+        if x == prevX {
+            edits = append(edits, edit{editInsert, b[y-1]})
+        } else {
+            edits = append(edits, edit{editDelete, a[x-1]})
+        }
+        x, y = prevX, prevY
+    }
+    for x > 0 && y > 0 {
+        edits = append(edits, edit{editEqual, a[x-1]})
+        x--
+        y--
+    }
+    for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+        edits[i], edits[j] = edits[j], edits[i]
+    }
+    return edits, true
+}
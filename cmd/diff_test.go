@@ -0,0 +1,243 @@
+package cmd
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+    "testing"
+    "unicode/utf8"
+)
+
+// applies edits to recover the expected (a) and actual (b) sides
+func applyEdits(edits []edit) (a, b []string) {
+    a, b = make([]string, 0), make([]string, 0)
+    for _, e := range edits {
+        if e.kind != editInsert {
+            a = append(a, e.text)
+        }
+        if e.kind != editDelete {
+            b = append(b, e.text)
+        }
+    }
+    return a, b
+}
+
+func cost(edits []edit) int {
+    n := 0
+    for _, e := range edits {
+        if e.kind != editEqual {
+            n++
+        }
+    }
+    return n
+}
+
+func TestMyersDiff(t *testing.T) {
+    cases := []struct {
+        a, b string
+        cost int
+    }{
+        {"", "", 0},
+        {"a b c", "a b c", 0},
+        {"", "a b", 2},
+        {"a b", "", 2},
+        {"a b c a b b a", "c b a b a c", 5},
+        {"1 2 3 4 5", "1 2 x 4 5", 2},
+        {"1 2 3", "0 1 2 3", 1},
+    }
+    for _, c := range cases {
+        a, b := strings.Fields(c.a), strings.Fields(c.b)
+        edits := myersDiff(a, b)
+        gotA, gotB := applyEdits(edits)
+        if strings.Join(gotA, " ") != c.a || strings.Join(gotB, " ") != c.b {
+            t.Errorf("%q -> %q: edits don't reproduce the inputs: %v", c.a, c.b, edits)
+        }
+        if n := cost(edits); n != c.cost {
+            t.Errorf("%q -> %q: %d edits, want %d", c.a, c.b, n, c.cost)
+        }
+    }
+}
+
+func TestMyersDiffFallback(t *testing.T) {
+    // no line in common past the first, far more edits than diffMaxCost
+    a, b := make([]string, 0), make([]string, 0)
+    for i := 0; i < 3*diffMaxCost; i++ {
+        a = append(a, fmt.Sprint("a", i))
+        b = append(b, fmt.Sprint("b", i))
+    }
+    a[0], b[0] = "same", "same"
+    a = append(a, "end")
+    b = append(b, "end")
+
+    edits := myersDiff(a, b)
+    gotA, gotB := applyEdits(edits)
+    if strings.Join(gotA, " ") != strings.Join(a, " ") || strings.Join(gotB, " ") != strings.Join(b, " ") {
+        t.Fatal("fallback edits don't reproduce the inputs")
+    }
+    if edits[0] != (edit{editEqual, "same"}) || edits[1] != (edit{editDelete, "a1"}) {
+        t.Errorf("fallback should keep the common prefix and start at the first mismatch, got %v", edits[:2])
+    }
+    if last := edits[len(edits)-1]; last != (edit{editEqual, "end"}) {
+        t.Errorf("fallback should keep the common suffix, got %v", last)
+    }
+}
+
+func TestRenderLines(t *testing.T) {
+    r := diffRenderer{color: false}
+    var out bytes.Buffer
+    r.render(&out, "1\n2\n3\n4\n5\n6\n7\n8\n", "1\n2\n3\n4\n5 x\n6\n7\n8\n", exactComparator{})
+    want := "- expected + actual\n" +
+        "  2\n" +
+        "  3\n" +
+        "  4\n" +
+        "- 5\n" +
+        "+ 5 >x<\n" +
+        "  6\n" +
+        "  7\n" +
+        "  8\n"
+    if out.String() != want {
+        t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+    }
+}
+
+func TestRenderLinesHunks(t *testing.T) {
+    expected, actual := make([]string, 0), make([]string, 0)
+    for i := 0; i < 20; i++ {
+        expected = append(expected, fmt.Sprint(i))
+        actual   = append(actual, fmt.Sprint(i))
+    }
+    actual[2], actual[17] = "x", "y"
+    var out bytes.Buffer
+    diffRenderer{}.render(&out, strings.Join(expected, "\n"), strings.Join(actual, "\n"), nil)
+    want := "- expected + actual\n" +
+        "  0\n  1\n- >2<\n+ >x<\n  3\n  4\n  5\n" +
+        "  ...\n" +
+        "  14\n  15\n  16\n- 17\n+ y\n  18\n  19\n"
+    if out.String() != want {
+        t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+    }
+}
+
+func TestRenderTokens(t *testing.T) {
+    var out bytes.Buffer
+    diffRenderer{}.render(&out, "1 2 3\n4 5 6 7 8 9 10 11 12\n", "1 2 3 4 5 x 7 8 9 10 11 12", tokenComparator{})
+    want := "1 2 3 4 5 [-6-] {+x+} 7 8 9 10 11\n"
+    if out.String() != want {
+        t.Errorf("got %q, want %q", out.String(), want)
+    }
+}
+
+func TestRenderTruncated(t *testing.T) {
+    expected, actual := make([]string, 0), make([]string, 0)
+    for i := 0; i < diffMaxInput+10; i++ {
+        expected = append(expected, fmt.Sprint(i))
+        actual   = append(actual, fmt.Sprint("x", i))
+    }
+    e, a := strings.Join(expected, "\n"), strings.Join(actual, "\n")
+
+    var out bytes.Buffer
+    diffRenderer{}.render(&out, e, a, nil)
+    lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+    // header, diffMaxShown lines and the truncation notice
+    if len(lines) != diffMaxShown+2 {
+        t.Errorf("rendered %d lines, want %d", len(lines), diffMaxShown+2)
+    }
+    if !strings.Contains(lines[len(lines)-1], "diff truncated") {
+        t.Errorf("missing truncation notice, last line %q", lines[len(lines)-1])
+    }
+
+    out.Reset()
+    diffRenderer{full: true}.render(&out, e, a, nil)
+    if n := strings.Count(out.String(), "\n"); n != 1+2*(diffMaxInput+10) {
+        t.Errorf("--full rendered %d lines, want every line", n)
+    }
+    if strings.Contains(out.String(), "truncated") {
+        t.Error("--full output shouldn't be truncated")
+    }
+
+    out.Reset()
+    diffRenderer{}.render(&out, e, a, tokenComparator{})
+    if !strings.Contains(out.String(), "diff truncated") {
+        t.Error("token diff wasn't truncated")
+    }
+}
+
+func TestRenderTruncatedPair(t *testing.T) {
+    // 39 deletions, the highlighted pair, then 200 more: the pair would
+    // take the 40th and 41st rendered line
+    expected := make([]string, 0)
+    for i := 0; i < diffMaxShown-1; i++ {
+        expected = append(expected, fmt.Sprint("d", i))
+    }
+    expected = append(expected, "X", "same")
+    for i := 0; i < 200; i++ {
+        expected = append(expected, fmt.Sprint("e", i))
+    }
+    var out bytes.Buffer
+    diffRenderer{}.render(&out, strings.Join(expected, "\n"), "Y\nsame", nil)
+    lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+    if len(lines) != diffMaxShown+1 {
+        t.Errorf("rendered %d lines, want the header, %d lines and the notice", len(lines), diffMaxShown-1)
+    }
+    if last := lines[len(lines)-1]; !strings.Contains(last, "diff truncated") {
+        t.Errorf("missing truncation notice, last line %q", last)
+    }
+}
+
+func TestRenderTokensTruncatedHunk(t *testing.T) {
+    // a single hunk far longer than the limit
+    expected, actual := make([]string, 0), make([]string, 0)
+    for i := 0; i < diffMaxShown*8; i++ {
+        expected = append(expected, fmt.Sprint(i))
+        actual   = append(actual, fmt.Sprint("x", i))
+    }
+    var out bytes.Buffer
+    diffRenderer{}.render(&out, strings.Join(expected, " "), strings.Join(actual, " "), tokenComparator{})
+    words := strings.Fields(out.String())
+    if n := strings.Count(out.String(), "[-") + strings.Count(out.String(), "{+"); n != diffMaxShown*8 {
+        t.Errorf("rendered %d changed tokens, want %d", n, diffMaxShown*8)
+    }
+    if !strings.Contains(out.String(), "diff truncated") {
+        t.Errorf("token diff wasn't truncated, last word %q", words[len(words)-1])
+    }
+}
+
+func TestRenderColor(t *testing.T) {
+    var plain, colored bytes.Buffer
+    diffRenderer{color: false}.render(&plain, "1\n2\n", "1\n3\n", nil)
+    diffRenderer{color: true}.render(&colored, "1\n2\n", "1\n3\n", nil)
+    if strings.Contains(plain.String(), "\033") {
+        t.Errorf("--no-color output contains escape codes: %q", plain.String())
+    }
+    if !strings.Contains(colored.String(), ansiRed) || !strings.Contains(colored.String(), ansiGreen) {
+        t.Errorf("colored output lacks red and green: %q", colored.String())
+    }
+    if stripANSI(colored.String()) != strings.NewReplacer(">", "", "<", "").Replace(plain.String()) {
+        t.Errorf("colored and plain output differ in text:\n%s\n%s", stripANSI(colored.String()), plain.String())
+    }
+
+    plain.Reset()
+    diffRenderer{color: false}.render(&plain, "1 2", "1 3", tokenComparator{})
+    if plain.String() != "1 [-2-] {+3+}\n" {
+        t.Errorf("--no-color token diff %q", plain.String())
+    }
+}
+
+func TestClip(t *testing.T) {
+    r := diffRenderer{}
+    short := strings.Repeat("é", diffMaxWidth)
+    if got := r.clip(short); got != short {
+        t.Errorf("clipped a line of exactly diffMaxWidth runes")
+    }
+    long := strings.Repeat("日本", diffMaxWidth)
+    got := r.clip(long)
+    if !utf8.ValidString(got) {
+        t.Errorf("clip cut inside a rune: %q", got)
+    }
+    if want := strings.Repeat("日本", diffMaxWidth/2) + "..."; got != want {
+        t.Errorf("clip kept %d runes, want %d", utf8.RuneCountInString(got)-3, diffMaxWidth)
+    }
+    if got := (diffRenderer{full: true}).clip(long); got != long {
+        t.Error("clipped with --full")
+    }
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// transcript of an interactive run. Each line is prefixed with the
+// direction it travelled: "> " solution to interactor, "< " interactor to solution
+type transcript struct {
+    mu  sync.Mutex
+    buf bytes.Buffer
+    // true when the next byte from a direction starts a new line
+    atStart map[string]bool
+}
+
+func (t *transcript) log(prefix string, p []byte) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    for _, b := range p {
+        if t.atStart[prefix] {
+            t.buf.WriteString(prefix)
+        }
+        t.buf.WriteByte(b)
+        t.atStart[prefix] = b == '\n'
+    }
+}
+
+// copies src to dst logging every chunk to the transcript
+// keeps draining src after dst is closed so the writer never blocks
+func relay(dst io.WriteCloser, src io.Reader, t *transcript, prefix string) {
+    buf := make([]byte, 4096)
+    for {
+        n, err := src.Read(buf)
+        if n > 0 {
+            t.log(prefix, buf[:n])
+            if dst != nil {
+                if _, werr := dst.Write(buf[:n]); werr != nil {
+                    dst.Close()
+                    dst = nil
+                }
+            }
+        }
+        if err != nil {
+            break
+        }
+    }
+    if dst != nil {
+        dst.Close()
+    }
+}
+
+// builds the solution for interactive problem p with toolchain c and runs it
+// against the user-supplied interactor once per sample input in
+// dir/tests/{problemId} (once with empty input if there are none).
+// The interactor is invoked testlib-style as: interactor <input> <output>
+// and its exit code decides the verdict. Both processes share the problem's
+// time limit. Transcripts are written to
+// dir/tests/{problemId}/interactN.log
+func runInteractive(dir string, p workspace.ProblemState, c workspace.Toolchain) ([]TestResult, error) {
+    if p.Interactor == "" {
+        return nil, fmt.Errorf("problem %s is interactive, register an interactor with --interactor", p.Id())
+    }
+    testDir := filepath.Join(dir, "tests", p.Id())
+    tests, err := workspace.ReadTests(testDir)
+    if err != nil {
+        return nil, err
+    }
+    if len(tests) == 0 {
+        tests = append(tests, workspace.Test{})
+    }
+
+    sol, err := buildProgram(filepath.Join(dir, p.FileName), c, buildDir(dir))
+    if err != nil {
+        return nil, err
+    }
+    defer sol.Close()
+
+    results := make([]TestResult, 0, len(tests))
+    for i, test := range tests {
+        result, err := interact(sol, p.Interactor, test, problemLimits(p).time)
+        if err != nil {
+            return nil, err
+        }
+        result.Index = i
+        logPath := filepath.Join(testDir, fmt.Sprintf("interact%d.log", i))
+        if err := os.WriteFile(logPath, []byte(result.Actual), 0644); err != nil {
+            return nil, err
+        }
+        results = append(results, result)
+    }
+    return results, nil
+}
+
+// runs sol and the interactor with cross-connected pipes on a single test
+func interact(sol *program, interactor string, test workspace.Test, limit time.Duration) (TestResult, error) {
+    // interactor reads the test from a file and writes its own output file
+    dir, err := os.MkdirTemp(sol.dir, "interact-")
+    if err != nil {
+        return TestResult{}, err
+    }
+    defer os.RemoveAll(dir)
+    inPath  := filepath.Join(dir, "input.txt")
+    outPath := filepath.Join(dir, "output.txt")
+    if err := os.WriteFile(inPath, []byte(test.Input), 0644); err != nil {
+        return TestResult{}, err
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), limit)
+    defer cancel()
+
+    solCmd   := sol.commandContext(ctx)
+    interCmd := exec.CommandContext(ctx, interactor, inPath, outPath)
+    interCmd.Dir = dir
+
+    var solErr, interErr bytes.Buffer
+    solCmd.Stderr   = &solErr
+    interCmd.Stderr = &interErr
+
+    solIn, err := solCmd.StdinPipe()
+    if err != nil {
+        return TestResult{}, err
+    }
+    solOut, err := solCmd.StdoutPipe()
+    if err != nil {
+        return TestResult{}, err
+    }
+    interIn, err := interCmd.StdinPipe()
+    if err != nil {
+        return TestResult{}, err
+    }
+    interOut, err := interCmd.StdoutPipe()
+    if err != nil {
+        return TestResult{}, err
+    }
+
+    start := time.Now()
+    if err := interCmd.Start(); err != nil {
+        return TestResult{}, err
+    }
+    waitSol, err := startContext(ctx, solCmd)
+    if err != nil {
+        interCmd.Process.Kill()
+        interCmd.Wait()
+        return TestResult{}, err
+    }
+
+    t := &transcript{atStart: map[string]bool{"> ": true, "< ": true}}
+    var wg sync.WaitGroup
+    wg.Add(2)
+    go func() { relay(interIn, solOut, t, "> "); wg.Done() }()
+    go func() { relay(solIn, interOut, t, "< "); wg.Done() }()
+    // pipes must be drained before Wait closes them
+    wg.Wait()
+    solWait   := waitSol()
+    interWait := interCmd.Wait()
+
+    result := TestResult{
+        Input:    test.Input,
+        Expected: test.Output,
+        Actual:   t.buf.String(),
+        Stderr:   solErr.String(),
+        Elapsed:  time.Since(start),
+    }
+    msg := strings.TrimSpace(interErr.String())
+
+    var solExit, exitErr *exec.ExitError
+    crashed := errors.As(solWait, &solExit)
+    if solWait != nil && !crashed {
+        return TestResult{}, solWait
+    }
+    // the interactor's verdict comes first: a solution that crashes after
+    // a wrong answer still gets WA. The crash is reported when the
+    // interactor accepted or ran out of input because of it.
+    switch {
+    case ctx.Err() != nil:
+        result.Label   = workspace.TimeLimitExceeded
+        result.Message = fmt.Sprintf("interaction exceeded %v", limit)
+    case crashed && (interWait == nil || cutOff(interWait)):
+        result.Label   = workspace.RuntimeError
+        result.Message = solExit.Error()
+    case interWait == nil:
+        result.Label   = workspace.Accepted
+        result.Message = msg
+    case errors.As(interWait, &exitErr):
+        switch exitErr.ExitCode() {
+        case checkerWA, checkerPE:
+            result.Label   = workspace.WrongAnswer
+            result.Message = msg
+        default:
+            result.Label   = workspace.DenialOfJudgement
+            result.Message = fmt.Sprintf("interactor exited with code %d: %s", exitErr.ExitCode(), msg)
+        }
+    default:
+        return TestResult{}, interWait
+    }
+    return result, nil
+}
+
+// true when the interactor failed because the solution went away: it hit
+// the end of the solution's output or was killed by a signal
+func cutOff(err error) bool {
+    var exitErr *exec.ExitError
+    if !errors.As(err, &exitErr) {
+        return false
+    }
+    code := exitErr.ExitCode()
+    return code == checkerEOF || code == -1
+}
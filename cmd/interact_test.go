@@ -0,0 +1,65 @@
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// writes an executable sh script to dir/name returning its path
+func writeScript(t *testing.T, dir, name, script string) string {
+    path := filepath.Join(dir, name)
+    if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestInteract(t *testing.T) {
+    // interactor sends the input's number and expects it doubled back
+    const interactor = `read n < "$1"; echo $n
+if ! read x; then echo "unexpected eof" >&2; exit 8; fi
+[ "$x" = $((n * 2)) ] || { echo "expected $((n * 2)), found $x" >&2; exit 1; }
+echo ok >&2`
+
+    cases := []struct {
+        name  string
+        sol   string
+        label workspace.SVLabel
+        msg   string
+    }{
+        {"accepted", `read n; echo $((n * 2))`, workspace.Accepted, "ok"},
+        {"wrong answer", `read n; echo $n`, workspace.WrongAnswer, "expected 6, found 3"},
+        // the interactor gave its verdict before the crash
+        {"wrong answer then crash", `read n; echo $n; read x; exit 3`, workspace.WrongAnswer, "expected 6, found 3"},
+        {"crash", `read n; exit 3`, workspace.RuntimeError, "exit status 3"},
+        {"accepted then crash", `read n; echo $((n * 2)); sleep 0.1; exit 3`, workspace.RuntimeError, "exit status 3"},
+        {"hung", `read n; sleep 10`, workspace.TimeLimitExceeded, "interaction exceeded 300ms"},
+    }
+    for _, c := range cases {
+        dir := t.TempDir()
+        inter := writeScript(t, dir, "interactor.sh", interactor)
+        writeScript(t, dir, "sol.sh", c.sol)
+        sol := &program{dir: dir, exec: "sh ./sol.sh"}
+
+        start := time.Now()
+        result, err := interact(sol, inter, workspace.Test{Input: "3\n"}, 300*time.Millisecond)
+        if err != nil {
+            t.Errorf("%s: %v", c.name, err)
+            continue
+        }
+        if result.Label != c.label || result.Message != c.msg {
+            t.Errorf("%s: %v %q, want %v %q", c.name, result.Label, result.Message, c.label, c.msg)
+        }
+        if elapsed := time.Since(start); elapsed > 5*time.Second {
+            t.Errorf("%s: took %v, the solution wasn't killed", c.name, elapsed)
+        }
+        if c.label == workspace.Accepted && !strings.HasPrefix(result.Actual, "< 3\n> 6\n") {
+            t.Errorf("%s: transcript %q", c.name, result.Actual)
+        }
+    }
+}
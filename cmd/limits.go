@@ -0,0 +1,89 @@
+package cmd
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "os/exec"
+    "time"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// limits used for problems whose limits weren't scraped
+const (
+    defaultTimeLimit   = 2 * time.Second
+    defaultMemoryLimit = 256 << 20
+)
+
+// resource limits for a single run of a solution
+type limits struct {
+    time   time.Duration
+    memory int64 // bytes
+}
+
+// returns the scraped limits of problem p or the defaults
+func problemLimits(p workspace.ProblemState) limits {
+    l := limits{p.TimeLimit, p.MemoryLimit}
+    if l.time <= 0 {
+        l.time = defaultTimeLimit
+    }
+    if l.memory <= 0 {
+        l.memory = defaultMemoryLimit
+    }
+    return l
+}
+
+// resources used by a finished run and the verdict implied by them
+// label is workspace.NA when the program exited normally within the limits
+type usage struct {
+    label   workspace.SVLabel
+    message string
+    elapsed time.Duration
+    memory  int64 // peak resident set size in bytes, 0 if unknown
+}
+
+// runs program p once with args and stdin/stdout/stderr, killing it when it
+// exceeds the time limit or its sampled memory exceeds the memory limit.
+// Memory of compound commands is sampled from the shell, the peak reported
+// after exit covers its children too.
+func runLimited(p *program, l limits, stdin io.Reader, stdout, stderr io.Writer, args ...string) (usage, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), l.time)
+    defer cancel()
+
+    c := p.commandContext(ctx, args...)
+    c.Stdin  = stdin
+    c.Stdout = stdout
+    c.Stderr = stderr
+
+    start := time.Now()
+    wait, err := startContext(ctx, c)
+    if err != nil {
+        return usage{}, err
+    }
+    stop := watchMemory(c.Process.Pid, l.memory, func() { killProcessGroup(c.Process) })
+    err = wait()
+    sampled := stop()
+
+    u := usage{elapsed: time.Since(start), memory: peakMemory(c.ProcessState)}
+    if sampled > u.memory {
+        u.memory = sampled
+    }
+
+    var exitErr *exec.ExitError
+    switch {
+    case ctx.Err() != nil:
+        u.label   = workspace.TimeLimitExceeded
+        u.message = fmt.Sprintf("killed after %v", l.time)
+    case u.memory > l.memory:
+        u.label   = workspace.MemoryLimitExceeded
+        u.message = fmt.Sprintf("used %d MB of %d MB", u.memory>>20, l.memory>>20)
+    case errors.As(err, &exitErr):
+        u.label   = workspace.RuntimeError
+        u.message = exitErr.Error()
+    case err != nil:
+        return usage{}, err
+    }
+    return u, nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "os/exec"
+    "strconv"
+    "strings"
+    "syscall"
+    "time"
+)
+
+// interval between resident set size samples of a running solution
+const memorySampleInterval = 5 * time.Millisecond
+
+// samples the resident set size of process pid from /proc until stop is
+// called, invoking kill once it exceeds limit bytes. stop returns the
+// largest sample seen.
+func watchMemory(pid int, limit int64, kill func()) (stop func() int64) {
+    done := make(chan struct{})
+    peak := make(chan int64, 1)
+    go func() {
+        var max int64
+        ticker := time.NewTicker(memorySampleInterval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-done:
+                peak <- max
+                return
+            case <-ticker.C:
+                rss, err := residentMemory(pid)
+                if err != nil {
+                    continue
+                }
+                if rss > max {
+                    max = rss
+                }
+                if rss > limit {
+                    kill()
+                }
+            }
+        }
+    }()
+    return func() int64 {
+        close(done)
+        return <-peak
+    }
+}
+
+// returns the current resident set size in bytes read from /proc/{pid}/status
+func residentMemory(pid int) (int64, error) {
+    f, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+    if err != nil {
+        return 0, err
+    }
+    defer f.Close()
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        // VmRSS:      1234 kB
+        line := scanner.Text()
+        if !strings.HasPrefix(line, "VmRSS:") {
+            continue
+        }
+        fields := strings.Fields(line)
+        if len(fields) < 2 {
+            break
+        }
+        kb, err := strconv.ParseInt(fields[1], 10, 64)
+        if err != nil {
+            return 0, err
+        }
+        return kb << 10, nil
+    }
+    return 0, fmt.Errorf("VmRSS not found for process %d", pid)
+}
+
+// puts the process started by c into a new process group led by it.
+// The group no longer gets the terminal's ctrl-c, so the process is
+// killed when forces dies instead.
+func setProcessGroup(c *exec.Cmd) {
+    c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+}
+
+// kills the process group led by p, which must have been started
+// after setProcessGroup
+func killProcessGroup(p *os.Process) {
+    syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
+
+// returns the peak resident set size in bytes of a finished process
+func peakMemory(state *os.ProcessState) int64 {
+    if state == nil {
+        return 0
+    }
+    rusage, ok := state.SysUsage().(*syscall.Rusage)
+    if !ok {
+        return 0
+    }
+    // linux reports ru_maxrss in kilobytes
+    return rusage.Maxrss << 10
+}
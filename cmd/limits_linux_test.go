@@ -0,0 +1,50 @@
+package cmd
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "syscall"
+    "testing"
+    "time"
+)
+
+func TestStartContext(t *testing.T) {
+    // the shell exits leaving a sleep behind in its process group
+    dir := t.TempDir()
+    p := &program{dir: dir, exec: "sleep 5 & echo $! > pid"}
+    ctx, cancel := context.WithCancel(context.Background())
+    wait, err := startContext(ctx, p.commandContext(ctx))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := wait(); err != nil {
+        t.Fatal(err)
+    }
+    data, err := os.ReadFile(filepath.Join(dir, "pid"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer syscall.Kill(pid, syscall.SIGKILL)
+
+    // done after the shell was reaped: its group id may belong to
+    // someone else by now, so nothing is killed
+    cancel()
+    time.Sleep(100 * time.Millisecond)
+    stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+    if err != nil {
+        t.Fatalf("the sleep was killed after the shell exited: %v", err)
+    }
+    // pid (comm) state ...
+    if state := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))[0]; state == "Z" {
+        t.Error("the sleep was killed after the shell exited")
+    }
+}
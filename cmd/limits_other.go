@@ -0,0 +1,25 @@
+//go:build !linux
+
+package cmd
+
+import (
+    "os"
+    "os/exec"
+)
+
+// process groups are only used on linux, elsewhere kills of compound
+// commands only reach the shell
+func setProcessGroup(c *exec.Cmd) {}
+
+func killProcessGroup(p *os.Process) {
+    p.Kill()
+}
+
+// memory sampling is only implemented for linux
+func watchMemory(pid int, limit int64, kill func()) (stop func() int64) {
+    return func() int64 { return 0 }
+}
+
+func peakMemory(state *os.ProcessState) int64 {
+    return 0
+}
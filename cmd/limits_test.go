@@ -0,0 +1,110 @@
+package cmd
+
+import (
+    "bytes"
+    "os"
+    "os/exec"
+    "runtime"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+func TestRunLimited(t *testing.T) {
+    cases := []struct {
+        name   string
+        exec   string
+        stdout string
+        label  workspace.SVLabel
+    }{
+        {"accepted", "cat", "1 2\n", workspace.NA},
+        {"compound", "cd . && tr 12 34", "3 4\n", workspace.NA},
+        {"pipeline", "cat | tr 12 34", "3 4\n", workspace.NA},
+        {"runtime error", "exit 3", "", workspace.RuntimeError},
+        {"time limit", "sleep 5", "", workspace.TimeLimitExceeded},
+        // the sleep keeps stdout open unless the whole group is killed
+        {"compound time limit", "true && sleep 5", "", workspace.TimeLimitExceeded},
+        {"pipeline time limit", "sleep 5 | cat", "", workspace.TimeLimitExceeded},
+    }
+    for _, c := range cases {
+        p := &program{dir: t.TempDir(), exec: c.exec}
+        var stdout, stderr bytes.Buffer
+        start := time.Now()
+        u, err := runLimited(p, limits{time: 300 * time.Millisecond, memory: 256 << 20}, strings.NewReader("1 2\n"), &stdout, &stderr)
+        if err != nil {
+            t.Errorf("%s: %v", c.name, err)
+            continue
+        }
+        if u.label != c.label {
+            t.Errorf("%s: label %v, want %v (%s)", c.name, u.label, c.label, u.message)
+        }
+        if stdout.String() != c.stdout {
+            t.Errorf("%s: stdout %q, want %q", c.name, stdout.String(), c.stdout)
+        }
+        if elapsed := time.Since(start); elapsed > 3*time.Second {
+            t.Errorf("%s: took %v, the program wasn't killed", c.name, elapsed)
+        }
+    }
+}
+
+func TestRunLimitedMemory(t *testing.T) {
+    if runtime.GOOS != "linux" {
+        t.Skip("memory is only measured on linux")
+    }
+    if _, err := exec.LookPath("python3"); err != nil {
+        t.Skip("python3 not found")
+    }
+    p := &program{dir: t.TempDir(), exec: `python3 -c "import time; x = bytearray(200 << 20); time.sleep(2)"`}
+    var stdout, stderr bytes.Buffer
+    u, err := runLimited(p, limits{time: 5 * time.Second, memory: 64 << 20}, strings.NewReader(""), &stdout, &stderr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if u.label != workspace.MemoryLimitExceeded {
+        t.Errorf("label %v, want %v (%s)", u.label, workspace.MemoryLimitExceeded, u.message)
+    }
+    if u.memory <= 64 << 20 {
+        t.Errorf("peak memory %d MB, want more than 64 MB", u.memory>>20)
+    }
+    if u.elapsed > time.Second {
+        t.Errorf("took %v, the program wasn't killed at the limit", u.elapsed)
+    }
+}
+
+func TestResidentMemory(t *testing.T) {
+    if runtime.GOOS != "linux" {
+        t.Skip("/proc is only read on linux")
+    }
+    rss, err := residentMemory(os.Getpid())
+    if err != nil {
+        t.Fatal(err)
+    }
+    if rss <= 0 {
+        t.Errorf("resident memory of the test process is %d", rss)
+    }
+    if _, err := residentMemory(-1); err == nil {
+        t.Error("expected an error for a missing process")
+    }
+}
+
+func TestSimpleCommand(t *testing.T) {
+    cases := map[string]bool{
+        "./sol":                    true,
+        "java -cp '/tmp/x' Main":   true,
+        "python3 A.py < input.txt": true,
+        "cd bin && ./sol":          false,
+        "./gen | ./sol":            false,
+        "./a; ./b":                 false,
+        "(./sol)":                  false,
+        `python3 -c "f(1); g()"`:   true,
+        `echo '|' \; x`:            true,
+        `echo "a" && "b"`:          false,
+    }
+    for cmd, want := range cases {
+        if got := simpleCommand(cmd); got != want {
+            t.Errorf("simpleCommand(%q) = %v, want %v", cmd, got, want)
+        }
+    }
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/pahyde/forces/workspace"
+    "github.com/spf13/cobra"
+)
+
+// default port Competitive Companion posts problems to
+const companionPort = 27121
+
+// problem payload sent by the Competitive Companion browser extension
+// https://github.com/jmerle/competitive-companion#explanation
+type companionProblem struct {
+    Name        string `json:"name"`
+    Group       string `json:"group"`
+    URL         string `json:"url"`
+    Interactive bool   `json:"interactive"`
+    MemoryLimit int64  `json:"memoryLimit"` // megabytes
+    TimeLimit   int64  `json:"timeLimit"`   // milliseconds
+    Tests       []struct {
+        Input  string `json:"input"`
+        Output string `json:"output"`
+    } `json:"tests"`
+    Batch struct {
+        ID   string `json:"id"`
+        Size int    `json:"size"`
+    } `json:"batch"`
+}
+
+// forces listen
+// forces listen --port 27121
+// receives problems from Competitive Companion and loads each completed
+// batch (a whole contest or a single problem) like "forces train"
+var listenCmd = &cobra.Command{
+    Use: "listen",
+    Short: "Receive problems from the Competitive Companion browser extension",
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        addr := fmt.Sprintf("127.0.0.1:%d", listenPort)
+        fmt.Printf("listening for Competitive Companion on %s\n", addr)
+        l := &companionListener{
+            workspace: openWorkspace(),
+            batches:   make(map[string][]companionProblem),
+        }
+        log.Fatal(http.ListenAndServe(addr, l))
+    },
+}
+
+var listenPort int
+
+func init() {
+    listenCmd.Flags().IntVarP(&listenPort, "port", "p", companionPort, "port Competitive Companion posts to")
+    rootCmd.AddCommand(listenCmd)
+}
+
+// collects posted problems by batch until every problem of a batch arrived
+type companionListener struct {
+    workspace *workspace.Workspace
+    mu        sync.Mutex
+    batches   map[string][]companionProblem
+}
+
+func (l *companionListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    var p companionProblem
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    batch := append(l.batches[p.Batch.ID], p)
+    if len(batch) < p.Batch.Size {
+        l.batches[p.Batch.ID] = batch
+        fmt.Printf("received %s (%d/%d)\n", p.Name, len(batch), p.Batch.Size)
+        return
+    }
+    delete(l.batches, p.Batch.ID)
+
+    contest := companionContest(l.workspace, batch)
+    if _, err := l.workspace.Load(contest); err != nil {
+        log.Println(err)
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    ids := make([]string, 0, len(contest.Problems))
+    for _, problem := range contest.Problems {
+        ids = append(ids, problem.Id)
+    }
+    fmt.Printf("loaded %s %s\n", contest.Id, strings.Join(ids, " "))
+}
+
+// converts a batch of Competitive Companion problems into a workspace.Contest
+// ids come from codeforces (contest, gym, group or problemset) or atcoder
+// urls when possible, otherwise the contest is
+// named after the problem group and problems after their title prefix
+// ("A. Title") or position in the batch
+func companionContest(w *workspace.Workspace, batch []companionProblem) workspace.Contest {
+    contest := workspace.Contest{Problems: make([]workspace.Problem, 0, len(batch))}
+    for i, p := range batch {
+        id := string(rune('A' + i))
+        if prefix, _, ok := strings.Cut(p.Name, "."); ok && workspace.ValidProblemId(prefix) {
+            id = prefix
+        }
+        if src, problemId, err := w.ParseSource(p.URL, ""); err == nil && problemId != "" {
+            contest.Id     = src.Contest
+            contest.Source = src
+            id = problemId
+        }
+        if contest.Id == "" {
+            contest.Id = sanitizeDir(p.Group)
+        }
+
+        tests := make([]workspace.Test, 0, len(p.Tests))
+        for _, t := range p.Tests {
+            tests = append(tests, workspace.Test{Input: t.Input, Output: t.Output})
+        }
+        contest.Problems = append(contest.Problems, workspace.Problem{
+            Id:          id,
+            Name:        p.Name,
+            Tests:       tests,
+            Interactive: p.Interactive,
+            TimeLimit:   time.Duration(p.TimeLimit) * time.Millisecond,
+            MemoryLimit: p.MemoryLimit << 20,
+            Url:         p.URL,
+        })
+    }
+    return contest
+}
+
+// replaces characters that are awkward in directory names
+func sanitizeDir(name string) string {
+    name = strings.Map(func(r rune) rune {
+        switch {
+        case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+            return r
+        }
+        return '_'
+    }, strings.TrimSpace(name))
+    if name == "" {
+        return "contest"
+    }
+    return name
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// reads a batch of payloads recorded from Competitive Companion
+func readCompanionBatch(t *testing.T, name string) []companionProblem {
+    data, err := os.ReadFile(filepath.Join("testdata", "companion", name))
+    if err != nil {
+        t.Fatal(err)
+    }
+    var batch []companionProblem
+    if err := json.Unmarshal(data, &batch); err != nil {
+        t.Fatal(err)
+    }
+    return batch
+}
+
+func TestCompanionContest(t *testing.T) {
+    w := &workspace.Workspace{}
+
+    cases := []struct {
+        file     string
+        id       string
+        source   workspace.Source
+        problems []string
+    }{
+        {"codeforces-1336.json", "1336", workspace.Source{Judge: workspace.CodeforcesJudge, Kind: workspace.ContestSource, Contest: "1336"}, []string{"A", "B"}},
+        {"atcoder-abc300.json", "abc300", workspace.Source{Judge: workspace.AtCoderJudge, Kind: workspace.ContestSource, Contest: "abc300"}, []string{"B"}},
+        {"codeforces-interactive.json", "1155", workspace.Source{Judge: workspace.CodeforcesJudge, Kind: workspace.ContestSource, Contest: "1155"}, []string{"E"}},
+        // urls of other judges fall back to the group and position in the batch
+        {"kattis-hello.json", "Kattis", workspace.Source{}, []string{"A"}},
+    }
+    for _, c := range cases {
+        batch := readCompanionBatch(t, c.file)
+        contest := companionContest(w, batch)
+        if contest.Id != c.id || contest.Source != c.source {
+            t.Errorf("%s: contest %q %+v, want %q %+v", c.file, contest.Id, contest.Source, c.id, c.source)
+        }
+        ids := make([]string, 0)
+        for _, p := range contest.Problems {
+            ids = append(ids, p.Id)
+        }
+        if !reflect.DeepEqual(ids, c.problems) {
+            t.Errorf("%s: problems %v, want %v", c.file, ids, c.problems)
+        }
+    }
+}
+
+func TestCompanionProblemIds(t *testing.T) {
+    // names of problems from other judges only give their id when it's a valid one
+    cases := []struct {
+        name, id string
+    }{
+        {"C2. Permutation", "C2"},
+        {"B. Two Words. Again", "B"},
+        {". Foo", "B"},
+        {"a/b. Bar", "B"},
+        {"1. Counting", "B"},
+        {"Hello World!", "B"},
+    }
+    for _, c := range cases {
+        batch := []companionProblem{
+            {Name: "A. First", Group: "Local", URL: "https://example.com/a"},
+            {Name: c.name, Group: "Local", URL: "https://example.com/b"},
+        }
+        contest := companionContest(&workspace.Workspace{}, batch)
+        if id := contest.Problems[1].Id; id != c.id {
+            t.Errorf("%q: id %q, want %q", c.name, id, c.id)
+        }
+    }
+}
+
+func TestCompanionContestProblem(t *testing.T) {
+    batch := readCompanionBatch(t, "codeforces-1336.json")
+    p := companionContest(&workspace.Workspace{}, batch).Problems[0]
+    want := workspace.Problem{
+        Id:          "A",
+        Name:        "A. Linova and Kingdom",
+        Tests:       []workspace.Test{
+            {Input: "7 4\n1 2\n1 3\n1 4\n3 5\n3 6\n4 7\n", Output: "7\n"},
+            {Input: "4 1\n1 2\n1 3\n2 4\n", Output: "2\n"},
+            {Input: "8 5\n7 5\n1 7\n6 1\n3 7\n8 3\n2 1\n4 5\n", Output: "9\n"},
+        },
+        TimeLimit:   2 * time.Second,
+        MemoryLimit: 256 << 20,
+        Url:         "https://codeforces.com/contest/1336/problem/A",
+    }
+    if !reflect.DeepEqual(p, want) {
+        t.Errorf("got %+v\nwant %+v", p, want)
+    }
+
+    batch = readCompanionBatch(t, "codeforces-interactive.json")
+    if p := companionContest(&workspace.Workspace{}, batch).Problems[0]; !p.Interactive || len(p.Tests) != 0 {
+        t.Errorf("interactive problem %+v", p)
+    }
+}
+
+func TestSanitizeDir(t *testing.T) {
+    cases := map[string]string{
+        "Kattis":                   "Kattis",
+        " Codeforces - Round 635 ": "Codeforces_-_Round_635",
+        "CSES - CSES Problem Set":  "CSES_-_CSES_Problem_Set",
+        "":                         "contest",
+    }
+    for in, want := range cases {
+        if got := sanitizeDir(in); got != want {
+            t.Errorf("sanitizeDir(%q) = %q, want %q", in, got, want)
+        }
+    }
+}
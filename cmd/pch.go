@@ -0,0 +1,104 @@
+package cmd
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// header precompiled for C++ solutions that include it
+const pchHeader = "bits/stdc++.h"
+
+var pchInclude = regexp.MustCompile(`(?m)^\s*#\s*include\s*<bits/stdc\+\+\.h>`)
+
+// returns c with the include dir of a precompiled bits/stdc++.h prepended
+// to its compile command when the source at path includes the header and c
+// compiles with g++. The header is precompiled once per compiler and flag
+// set into appDir/pch/{hash of compiler and flags}, so changing the flags
+// builds a new one. gcc ignores a precompiled header built with other flags
+// and falls back to the plain header, so failures only cost speed: they're
+// reported and c is returned unchanged.
+func withPrecompiledHeader(appDir, path string, c workspace.Toolchain) workspace.Toolchain {
+    compiler, flags, ok := gccFlags(c.Compile)
+    if !ok {
+        return c
+    }
+    src, err := os.ReadFile(path)
+    if err != nil || !pchInclude.Match(src) {
+        return c
+    }
+    dir, err := precompileHeader(appDir, compiler, flags)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "not using a precompiled header: %v\n", err)
+        return c
+    }
+    compile := strings.TrimSpace(c.Compile)
+    c.Compile = compiler + " -I " + shellQuote(dir) + strings.TrimPrefix(compile, compiler)
+    return c
+}
+
+// splits a single g++ compile command into the compiler and the flags that
+// affect code generation, dropping the output file and source placeholders.
+// !ok for other compilers and for compound shell commands
+func gccFlags(compile string) (compiler string, flags []string, ok bool) {
+    fields := strings.Fields(compile)
+    if len(fields) == 0 || !strings.HasPrefix(filepath.Base(fields[0]), "g++") {
+        return "", nil, false
+    }
+    for i := 1; i < len(fields); i++ {
+        f := fields[i]
+        switch {
+        case strings.ContainsAny(f, "&|;<>`$"):
+            return "", nil, false
+        case f == "-o":
+            i++
+        case strings.Contains(f, "{{"), strings.HasPrefix(f, "-l"), strings.HasPrefix(f, "-L"):
+        default:
+            flags = append(flags, f)
+        }
+    }
+    return fields[0], flags, true
+}
+
+// returns the include dir holding bits/stdc++.h.gch precompiled by
+// compiler with flags, building it first if needed
+func precompileHeader(appDir, compiler string, flags []string) (string, error) {
+    key := sha256.Sum256([]byte(compiler + "\x00" + strings.Join(flags, "\x00")))
+    dir := filepath.Join(appDir, "pch", hex.EncodeToString(key[:])[:16])
+    gch := filepath.Join(dir, pchHeader + ".gch")
+    if _, err := os.Stat(gch); err == nil {
+        return dir, nil
+    }
+    if err := os.MkdirAll(filepath.Dir(gch), 0755); err != nil {
+        return "", err
+    }
+
+    // the header is compiled through a stub including it, into a temporary
+    // file renamed into place so concurrent builds never see a partial one
+    stub := filepath.Join(dir, "stdc++.h")
+    if err := os.WriteFile(stub, []byte("#include <" + pchHeader + ">\n"), 0644); err != nil {
+        return "", err
+    }
+    tmp := fmt.Sprintf("%s.%d.tmp", gch, os.Getpid())
+    args := append(append([]string{}, flags...), "-x", "c++-header", stub, "-o", tmp)
+    cmd := compiler
+    for _, arg := range args {
+        cmd += " " + shellQuote(arg)
+    }
+    fmt.Fprintf(os.Stderr, "precompiling %s for %s %s\n", pchHeader, compiler, strings.Join(flags, " "))
+    out, err := shell(dir, cmd).CombinedOutput()
+    if err != nil {
+        os.Remove(tmp)
+        return "", fmt.Errorf("precompiling %s failed: %v\n%s", pchHeader, err, out)
+    }
+    if err := os.Rename(tmp, gch); err != nil {
+        return "", err
+    }
+    return dir, nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+    "os"
+    "os/exec"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+func TestGccFlags(t *testing.T) {
+    cases := []struct {
+        compile  string
+        compiler string
+        flags    []string
+        ok       bool
+    }{
+        {"g++ -std=c++17 -O2 -o {{binary}} {{source}}", "g++", []string{"-std=c++17", "-O2"}, true},
+        {"g++-12 -O2 {{source}} -o {{binary}} -DLOCAL", "g++-12", []string{"-O2", "-DLOCAL"}, true},
+        {"/usr/bin/g++ -o out -Wall {{source}}", "/usr/bin/g++", []string{"-Wall"}, true},
+        {"g++ -O2 -I{{dir}} {{source}} -o {{binary}} -lm -L/opt/lib", "g++", []string{"-O2"}, true},
+        {"g++ {{source}}", "g++", nil, true},
+        // compound commands could do anything around the compiler
+        {"g++ -O2 {{source}} && strip {{binary}}", "", nil, false},
+        {"g++ -O2 {{source}} 2>&1 | head", "", nil, false},
+        {"g++ -O2 {{source}}; true", "", nil, false},
+        {"g++ $CXXFLAGS {{source}}", "", nil, false},
+        {"clang++ -O2 -o {{binary}} {{source}}", "", nil, false},
+        {"gcc -O2 -o {{binary}} {{source}}", "", nil, false},
+        {"", "", nil, false},
+    }
+    for _, c := range cases {
+        compiler, flags, ok := gccFlags(c.compile)
+        if compiler != c.compiler || !reflect.DeepEqual(flags, c.flags) || ok != c.ok {
+            t.Errorf("gccFlags(%q) = %q, %q, %v, want %q, %q, %v", c.compile, compiler, flags, ok, c.compiler, c.flags, c.ok)
+        }
+    }
+}
+
+func TestPrecompiledHeader(t *testing.T) {
+    if testing.Short() {
+        t.Skip("precompiling takes seconds")
+    }
+    if _, err := exec.LookPath("g++"); err != nil {
+        t.Skip("g++ not found")
+    }
+    appDir, dir := t.TempDir(), t.TempDir()
+    path := filepath.Join(dir, "A.cpp")
+    src  := "#include <bits/stdc++.h>\nint main() { std::vector<int> v{1, 2}; std::cout << v[0] + v[1] << std::endl; }\n"
+    if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+        t.Fatal(err)
+    }
+    c := workspace.Toolchain{Name: "cpp17", Compile: "g++ -std=c++17 -O2 -o {{binary}} {{source}}", Execute: "{{binary}}"}
+
+    withPch := withPrecompiledHeader(appDir, path, c)
+    gchs, _ := filepath.Glob(filepath.Join(appDir, "pch", "*", pchHeader + ".gch"))
+    if len(gchs) != 1 {
+        t.Fatalf("precompiled headers %v, want one", gchs)
+    }
+    if !strings.HasPrefix(withPch.Compile, "g++ -I ") || !strings.HasSuffix(withPch.Compile, c.Compile[len("g++"):]) {
+        t.Errorf("compile command %q", withPch.Compile)
+    }
+
+    // -H lists the headers used, "!" marking a valid precompiled one
+    out, err := shell(dir, expandCommand(withPch.Compile + " -H", path, dir)).CombinedOutput()
+    if err != nil {
+        t.Fatalf("compiling with the precompiled header: %v\n%s", err, out)
+    }
+    if !strings.Contains(string(out), "! " + gchs[0]) {
+        t.Errorf("the precompiled header wasn't used:\n%s", out)
+    }
+    run, err := shell(dir, expandCommand(withPch.Execute, path, dir)).Output()
+    if err != nil || string(run) != "3\n" {
+        t.Errorf("solution printed %q, %v", run, err)
+    }
+
+    // a second build reuses the header, other flags get their own
+    if again := withPrecompiledHeader(appDir, path, c); again.Compile != withPch.Compile {
+        t.Errorf("second build compiles with %q", again.Compile)
+    }
+    c.Compile = "g++ -std=c++17 -O0 -o {{binary}} {{source}}"
+    withPrecompiledHeader(appDir, path, c)
+    if gchs, _ := filepath.Glob(filepath.Join(appDir, "pch", "*", pchHeader + ".gch")); len(gchs) != 2 {
+        t.Errorf("precompiled headers %v, want one per flag set", gchs)
+    }
+
+    // sources not including the header compile as before
+    if err := os.WriteFile(path, []byte("#include <cstdio>\nint main() {}\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    if plain := withPrecompiledHeader(appDir, path, c); plain.Compile != c.Compile {
+        t.Errorf("compile command %q for a source without bits/stdc++.h", plain.Compile)
+    }
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "sync"
+)
+
+// live download progress of the problems of a contest, one line per problem:
+//   1457 A 100%
+//   1457 B  38%
+//   1457 C  12KB
+// On a terminal every line is redrawn in place as bytes arrive,
+// otherwise a line is printed once a problem finishes downloading.
+type progressDisplay struct {
+    mu        sync.Mutex
+    w         io.Writer
+    live      bool
+    contestId string
+    order     []string // problem ids in the order they started
+    lines     map[string]string
+    drawn     int // lines on screen from the last redraw
+}
+
+func newProgressDisplay(contestId string) *progressDisplay {
+    live := true
+    if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+        live = false
+    }
+    return &progressDisplay{
+        w:         os.Stdout,
+        live:      live,
+        contestId: contestId,
+        lines:     make(map[string]string),
+    }
+}
+
+// records that read of total bytes (-1 if unknown) of a problem page
+// arrived, read == total once it's done. Safe for concurrent use.
+func (d *progressDisplay) update(problemId string, read, total int64) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+
+    if _, ok := d.lines[problemId]; !ok {
+        d.order = append(d.order, problemId)
+    }
+    done := total >= 0 && read == total
+    var status string
+    switch {
+    case done:
+        status = "100%"
+    case total > 0:
+        status = fmt.Sprintf("%3d%%", read*100/total)
+    default:
+        status = fmt.Sprintf("%dKB", read>>10)
+    }
+    line := fmt.Sprintf("%s %s %s", d.contestId, problemId, status)
+    if d.lines[problemId] == line {
+        return
+    }
+    d.lines[problemId] = line
+
+    if !d.live {
+        if done {
+            fmt.Fprintln(d.w, line)
+        }
+        return
+    }
+    d.redraw()
+}
+
+// moves the cursor back over the previous drawing and prints every line again
+func (d *progressDisplay) redraw() {
+    var b strings.Builder
+    if d.drawn > 0 {
+        fmt.Fprintf(&b, "\033[%dA", d.drawn)
+    }
+    for _, id := range d.order {
+        b.WriteString("\r\033[K")
+        b.WriteString(d.lines[id])
+        b.WriteByte('\n')
+    }
+    d.drawn = len(d.order)
+    io.WriteString(d.w, b.String())
+}
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "os/exec"
+    "os/signal"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "github.com/pahyde/forces/workspace"
+    "github.com/peterh/liner"
+    "github.com/spf13/cobra"
+)
+
+// forces session
+// forces 1336 A> test .      <- tests A, moving on to B once it passes
+// forces 1336 B> status
+var sessionCmd = &cobra.Command{
+    Use: "session",
+    Short: "Start an interactive forces> prompt",
+    Long: `Start a prompt running forces commands without retyping forces.
+"." stands for the current problem shown in the prompt, which moves to the
+next problem once "test ." passes. next, prev and problem <id> move it by
+hand, help lists commands and exit (or ctrl-d) leaves.`,
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        w := openWorkspace()
+        exe, err := os.Executable()
+        if err != nil {
+            log.Fatal(err)
+        }
+        r := &repl{w: w, exe: exe}
+        if err := r.run(); err != nil {
+            log.Fatal(err)
+        }
+    },
+}
+
+func init() {
+    rootCmd.AddCommand(sessionCmd)
+}
+
+// commands handled by the prompt itself rather than forces
+var replBuiltins = []string{"exit", "help", "next", "prev", "problem", "quit"}
+
+type repl struct {
+    w       *workspace.Workspace
+    exe     string // forces binary running commands
+    session workspace.Session
+    cursor  string // problem id "." stands for
+}
+
+// path of the prompt's history file in the app dir
+func (r *repl) historyPath() string {
+    return filepath.Join(r.w.AppDir, "history")
+}
+
+func (r *repl) run() error {
+    line := liner.NewLiner()
+    defer line.Close()
+    line.SetCtrlCAborts(true)
+    line.SetTabCompletionStyle(liner.TabPrints)
+    line.SetWordCompleter(r.complete)
+
+    if f, err := os.Open(r.historyPath()); err == nil {
+        line.ReadHistory(f)
+        f.Close()
+    }
+    defer r.saveHistory(line)
+
+    for {
+        r.reload()
+        input, err := line.Prompt(r.prompt())
+        if errors.Is(err, liner.ErrPromptAborted) {
+            continue
+        }
+        if errors.Is(err, io.EOF) {
+            fmt.Println()
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        args := strings.Fields(input)
+        if len(args) == 0 {
+            continue
+        }
+        line.AppendHistory(input)
+        if quit := r.exec(args); quit {
+            return nil
+        }
+    }
+}
+
+func (r *repl) saveHistory(line *liner.State) {
+    f, err := os.Create(r.historyPath())
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "saving history: %v\n", err)
+        return
+    }
+    defer f.Close()
+    line.WriteHistory(f)
+}
+
+// re-reads the session, commands like train replace it. The cursor starts
+// at the most recently modified solution of a new session.
+func (r *repl) reload() {
+    s, err := r.w.ReadSession()
+    if err != nil {
+        r.session = workspace.Session{}
+        r.cursor  = ""
+        return
+    }
+    if s.Path != r.session.Path {
+        r.cursor = ""
+        if p, err := s.ProblemRecent(); err == nil {
+            r.cursor = p.Id()
+        }
+    }
+    r.session = s
+}
+
+// forces 1336 A> , or forces> without a session
+func (r *repl) prompt() string {
+    if r.session.Path == "" {
+        return "forces> "
+    }
+    contest := contestLabel(r.session.Source)
+    if r.cursor == "" {
+        return fmt.Sprintf("forces %s> ", contest)
+    }
+    return fmt.Sprintf("forces %s %s> ", contest, r.cursor)
+}
+
+// runs a builtin or forces command, reporting whether to quit
+func (r *repl) exec(args []string) bool {
+    switch args[0] {
+    case "exit", "quit":
+        return true
+    case "help":
+        fmt.Println("forces commands: " + strings.Join(forcesCommands(), " "))
+        fmt.Println("prompt commands: " + strings.Join(replBuiltins, " "))
+        fmt.Println(`"." is the current problem, e.g. test .`)
+        return false
+    case "next":
+        r.move(1)
+        return false
+    case "prev":
+        r.move(-1)
+        return false
+    case "problem":
+        if len(args) != 2 {
+            fmt.Println("usage: problem <id>")
+        } else if _, ok := r.session.ProblemById(args[1]); !ok {
+            fmt.Printf("problem %s not found in current session\n", args[1])
+        } else {
+            r.cursor = args[1]
+            r.printCursor()
+        }
+        return false
+    case "session":
+        fmt.Println("already in a session prompt")
+        return false
+    }
+
+    dot := false
+    for i, arg := range args {
+        if arg == "." && r.cursor != "" {
+            args[i] = r.cursor
+            dot = true
+        }
+    }
+    if err := r.forces(args); err != nil {
+        return false
+    }
+    // a passing "test ." moves on to the next problem
+    if dot && args[0] == "test" {
+        r.reload()
+        if p, ok := r.session.ProblemById(r.cursor); ok && p.Tests.Total > 0 && p.Tests.Passed == p.Tests.Total {
+            r.move(1)
+        }
+    }
+    return false
+}
+
+// runs forces with args, passing on the global flags of the prompt.
+// Interrupts stop the command rather than the prompt.
+func (r *repl) forces(args []string) error {
+    if baseUrl != "" {
+        args = append(args, "--base-url", baseUrl)
+    }
+    if refresh {
+        args = append(args, "--refresh")
+    }
+    cmd := exec.Command(r.exe, args...)
+    cmd.Stdin  = os.Stdin
+    cmd.Stdout = os.Stdout
+    cmd.Stderr = os.Stderr
+
+    interrupt := make(chan os.Signal, 1)
+    signal.Notify(interrupt, os.Interrupt)
+    defer signal.Stop(interrupt)
+    return cmd.Run()
+}
+
+// moves the cursor by delta problems and shows where it is
+func (r *repl) move(delta int) {
+    for i, p := range r.session.Problems {
+        if p.Id() != r.cursor {
+            continue
+        }
+        j := i + delta
+        if j < 0 || j >= len(r.session.Problems) {
+            fmt.Println("no more problems")
+            return
+        }
+        r.cursor = r.session.Problems[j].Id()
+        r.printCursor()
+        return
+    }
+}
+
+// A B C D E F
+//   ^
+func (r *repl) printCursor() {
+    var ids, mark strings.Builder
+    for _, p := range r.session.Problems {
+        c := " "
+        if p.Id() == r.cursor {
+            c = "^"
+        }
+        ids.WriteString(p.Id() + " ")
+        mark.WriteString(c + strings.Repeat(" ", len(p.Id())))
+    }
+    fmt.Println(strings.TrimSpace(ids.String()))
+    fmt.Println(strings.TrimRight(mark.String(), " "))
+}
+
+// completes command names as the first word and problem ids after it
+func (r *repl) complete(line string, pos int) (head string, completions []string, tail string) {
+    head, tail = line[:pos], line[pos:]
+    start := strings.LastIndexAny(head, " \t") + 1
+    word := head[start:]
+    head = head[:start]
+
+    var candidates []string
+    if strings.TrimSpace(head) == "" {
+        candidates = append(forcesCommands(), replBuiltins...)
+        sort.Strings(candidates)
+    } else {
+        for _, p := range r.session.Problems {
+            candidates = append(candidates, p.Id())
+        }
+    }
+    for _, c := range candidates {
+        if strings.HasPrefix(c, word) {
+            completions = append(completions, c + " ")
+        }
+    }
+    return head, completions, tail
+}
+
+// names of the forces commands usable from the prompt
+func forcesCommands() []string {
+    names := make([]string, 0)
+    for _, c := range rootCmd.Commands() {
+        if c.Hidden || c.Name() == "session" || c.Name() == "help" || c.Name() == "completion" {
+            continue
+        }
+        names = append(names, c.Name())
+    }
+    return names
+}
@@ -2,8 +2,10 @@ package cmd
 
 import (
     "fmt"
+    "log"
     "os"
 
+    "github.com/pahyde/forces/workspace"
     "github.com/spf13/cobra"
 )
 
@@ -20,9 +22,36 @@ var rootCmd = &cobra.Command{
 }
 
 
+var (
+    // overrides the configured codeforces base url
+    baseUrl string
+    // bypasses cached pages
+    refresh bool
+)
+
+func init() {
+    rootCmd.PersistentFlags().StringVar(&baseUrl, "base-url", "", "codeforces base url, e.g. a mirror like https://codeforces.ml")
+    rootCmd.PersistentFlags().BoolVar(&refresh, "refresh", false, "download pages again instead of using cached copies")
+}
+
 func Execute() {
     if err := rootCmd.Execute(); err != nil {
         fmt.Println(err)
         os.Exit(1)
     }
 }
+
+// returns a workspace creating contest directories in the current directory
+func openWorkspace() *workspace.Workspace {
+    w, err := workspace.New(".")
+    if err != nil {
+        log.Fatal(err)
+    }
+    if baseUrl != "" {
+        w.BaseUrl = baseUrl
+    }
+    if refresh && w.Cache != nil {
+        w.Cache.Refresh = true
+    }
+    return w
+}
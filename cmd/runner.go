@@ -0,0 +1,215 @@
+package cmd
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+// outcome of running a solution against a single sample test
+type TestResult struct {
+    Index    int
+    Label    workspace.SVLabel
+    Input    string
+    Expected string
+    Actual   string
+    Stderr   string
+    Message  string // comparator mismatch or failure details
+    Elapsed  time.Duration
+    Memory   int64 // peak resident set size in bytes, 0 if unknown
+}
+
+// substitutes the placeholders of a toolchain command for the source
+// file at path built in the work directory dir
+func expandCommand(cmd, path, dir string) string {
+    return strings.NewReplacer(
+        "{{source}}", shellQuote(path),
+        "{{binary}}", shellQuote(filepath.Join(dir, "sol")),
+        "{{dir}}",    shellQuote(dir),
+        "{{path}}",   shellQuote(strings.TrimSuffix(path, filepath.Ext(path))),
+    ).Replace(cmd)
+}
+
+// compiled program that can be run from its work directory
+type program struct {
+    dir    string // holds build output such as ./sol
+    exec   string // shell command running the program
+    cached bool   // dir belongs to the build cache and outlives the program
+}
+
+// returns a command running the program with args
+func (p *program) command(args ...string) *exec.Cmd {
+    return p.commandContext(context.Background(), args...)
+}
+
+// like command but the program is killed once ctx is done.
+// The shell execs simple commands so that kills and resource usage apply
+// to the program itself rather than to sh. Compound commands like
+// "cd bin && ./sol" keep the shell, which runs in its own process group
+// so startContext can kill the whole pipeline.
+func (p *program) commandContext(ctx context.Context, args ...string) *exec.Cmd {
+    cmd := p.exec
+    if simpleCommand(cmd) {
+        cmd = "exec " + cmd
+    }
+    for _, arg := range args {
+        cmd += " " + shellQuote(arg)
+    }
+    c := exec.CommandContext(ctx, "sh", "-c", cmd)
+    c.Dir = p.dir
+    setProcessGroup(c)
+    return c
+}
+
+// starts c and kills its process group if ctx is done before c exits, so
+// children of compound commands don't outlive it (and keep its pipes open).
+// The returned function waits for c in place of c.Wait; once c is reaped
+// its pid may be reused, so the group is left alone from then on.
+func startContext(ctx context.Context, c *exec.Cmd) (wait func() error, err error) {
+    if err := c.Start(); err != nil {
+        return nil, err
+    }
+    exited := make(chan struct{})
+    go func() {
+        select {
+        case <-ctx.Done():
+            select {
+            case <-exited:
+            default:
+                killProcessGroup(c.Process)
+            }
+        case <-exited:
+        }
+    }()
+    return func() error {
+        err := c.Wait()
+        close(exited)
+        return err
+    }, nil
+}
+
+// true for commands sh can exec: no lists, pipelines or subshells
+// outside of quotes
+func simpleCommand(cmd string) bool {
+    var quote rune
+    escaped := false
+    for _, r := range cmd {
+        switch {
+        case escaped:
+            escaped = false
+        case quote != 0:
+            if r == quote {
+                quote = 0
+            } else if r == '\\' && quote == '"' {
+                escaped = true
+            }
+        case r == '\\':
+            escaped = true
+        case r == '\'' || r == '"':
+            quote = r
+        case strings.ContainsRune(";&|()`\n", r):
+            return false
+        }
+    }
+    return true
+}
+
+// removes the work directory unless it's cached
+func (p *program) Close() error {
+    if p.cached {
+        return nil
+    }
+    return os.RemoveAll(p.dir)
+}
+
+// builds the solution for problem p with toolchain c and runs it against
+// every sample test in dir/tests/{problemId}, judging output with checker
+func runTests(dir string, p workspace.ProblemState, c workspace.Toolchain, checker Checker) ([]TestResult, error) {
+    tests, err := workspace.ReadTests(filepath.Join(dir, "tests", p.Id()))
+    if err != nil {
+        return nil, err
+    }
+    if len(tests) == 0 {
+        return nil, fmt.Errorf("no sample tests found for problem %s", p.Id())
+    }
+
+    sol, err := buildProgram(filepath.Join(dir, p.FileName), c, buildDir(dir))
+    if err != nil {
+        return nil, err
+    }
+    defer sol.Close()
+
+    results := make([]TestResult, 0, len(tests))
+    for i, test := range tests {
+        var stdout, stderr bytes.Buffer
+        u, err := runLimited(sol, problemLimits(p), strings.NewReader(test.Input), &stdout, &stderr)
+        if err != nil {
+            return nil, err
+        }
+        result := TestResult{
+            Index:    i,
+            Label:    u.label,
+            Input:    test.Input,
+            Expected: test.Output,
+            Actual:   stdout.String(),
+            Stderr:   stderr.String(),
+            Message:  u.message,
+            Elapsed:  u.elapsed,
+            Memory:   u.memory,
+        }
+        if result.Label == workspace.NA {
+            label, msg, err := checker.Check(test, result.Actual)
+            if err != nil {
+                return nil, err
+            }
+            result.Label   = label
+            result.Message = msg
+        }
+        results = append(results, result)
+    }
+    return results, nil
+}
+
+// returns a command running cmd with sh in the working directory dir
+func shell(dir, cmd string) *exec.Cmd {
+    c := exec.Command("sh", "-c", cmd)
+    c.Dir = dir
+    return c
+}
+
+// single quotes s for use as one sh word
+func shellQuote(s string) string {
+    return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// true when outputs are equal ignoring trailing whitespace on each line
+// and trailing blank lines
+func sameOutput(expected, actual string) bool {
+    return normalize(expected) == normalize(actual)
+}
+
+func normalize(s string) string {
+    lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+    for i, line := range lines {
+        lines[i] = strings.TrimRight(line, " \t")
+    }
+    return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// returns the number of accepted results
+func countPassed(results []TestResult) int {
+    passed := 0
+    for _, r := range results {
+        if r.Label == workspace.Accepted {
+            passed++
+        }
+    }
+    return passed
+}
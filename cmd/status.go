@@ -0,0 +1,59 @@
+package cmd
+
+import (
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "strings"
+
+    "github.com/pahyde/forces/workspace"
+    "github.com/spf13/cobra"
+)
+
+// forces status
+// 1336
+//     test   submit
+// A   3/3    accepted
+// B   1/2    wrong answer
+// C   0/3    unsubmitted
+var statusCmd = &cobra.Command{
+    Use: "status",
+    Short: "Show sample test and submission verdicts of the session",
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        session, err := openWorkspace().ReadSession()
+        if err != nil {
+            log.Fatal(err)
+        }
+        printStatus(os.Stdout, session)
+    },
+}
+
+func init() {
+    rootCmd.AddCommand(statusCmd)
+}
+
+// short name of a contest, 1336 rather than contest:1336
+func contestLabel(s workspace.Source) string {
+    return strings.TrimPrefix(s.String(), "contest:")
+}
+
+func printStatus(out io.Writer, s workspace.Session) {
+    width := 0
+    for _, p := range s.Problems {
+        if len(p.Id()) > width {
+            width = len(p.Id())
+        }
+    }
+    fmt.Fprintf(out, "%s\n", contestLabel(s.Source))
+    fmt.Fprintf(out, "%-*s  %-8s %s\n", width, "", "test", "submit")
+    for _, p := range s.Problems {
+        tests := fmt.Sprintf("%d/%d", p.Tests.Passed, p.Tests.Total)
+        submit := "unsubmitted"
+        if p.Submission.Label != workspace.NA {
+            submit = p.Submission.Label.String()
+        }
+        fmt.Fprintf(out, "%-*s  %-8s %s\n", width, p.Id(), tests, submit)
+    }
+}
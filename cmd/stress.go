@@ -0,0 +1,283 @@
+package cmd
+
+import (
+    "bytes"
+    "fmt"
+    "log"
+    "path/filepath"
+    "runtime"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/pahyde/forces/workspace"
+    "github.com/spf13/cobra"
+)
+
+// limits for the generator and brute force, which are expected to be slow
+// but not to hang
+var referenceLimits = limits{time: 30 * time.Second, memory: 1 << 30}
+
+// forces stress A
+// forces stress A --gen gen.cpp --brute brute.cpp -n 500 -j 4
+// 1) compile generator, brute force and solution with their toolchains
+// 2) for seed = 1, 2, 3...: gen seed | brute, gen seed | sol, compare
+// 3) save the first failing input and brute force output as a new sample test
+var stressCmd = &cobra.Command{
+    Use: "stress [problem]",
+    Short: "Compare a solution against a brute force on generated tests",
+    Args: cobra.MaximumNArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        if err := stressProblem(openWorkspace(), args); err != nil {
+            log.Fatal(err)
+        }
+    },
+}
+
+// stress tests the solution of the problem named by args (default: most
+// recently modified), saving the first failing input as a sample test.
+// Programs built so far are cleaned up before any error is returned.
+func stressProblem(w *workspace.Workspace, args []string) error {
+    session, err := w.ReadSession()
+    if err != nil {
+        return err
+    }
+    registry, err := w.ReadTemplates()
+    if err != nil {
+        return err
+    }
+
+    problem, err := resolveProblem(session, args)
+    if err != nil {
+        return err
+    }
+    t, ok := registry.GetTemplate(problem.Template)
+    if !ok {
+        return fmt.Errorf("couldn't find template %s in templates list", problem.Template)
+    }
+    toolchain, err := registry.ToolchainFor(t)
+    if err != nil {
+        return err
+    }
+    if problem.Interactive {
+        return fmt.Errorf("can't stress test interactive problem %s", problem.Id())
+    }
+
+    // generator and brute force default to gen{ext} and brute{ext} in the contest dir
+    ext := filepath.Ext(problem.FileName)
+    if stressGen == "" {
+        stressGen = "gen" + ext
+    }
+    if stressBrute == "" {
+        stressBrute = "brute" + ext
+    }
+    build := func(path string) (*program, error) {
+        if !filepath.IsAbs(path) {
+            path = filepath.Join(session.Path, path)
+        }
+        c, ok := registry.ToolchainForExt(filepath.Ext(path))
+        if !ok {
+            c = toolchain
+        }
+        c = withPrecompiledHeader(w.AppDir, path, c)
+        return buildProgram(path, c, buildDir(session.Path))
+    }
+    gen, err := build(stressGen)
+    if err != nil {
+        return err
+    }
+    defer gen.Close()
+    brute, err := build(stressBrute)
+    if err != nil {
+        return err
+    }
+    defer brute.Close()
+    sol, err := build(problem.FileName)
+    if err != nil {
+        return err
+    }
+    defer sol.Close()
+
+    judge, err := problemChecker(session.Path, problem, registry, toolchain)
+    if err != nil {
+        return err
+    }
+    if c, ok := judge.(*programChecker); ok {
+        defer c.Close()
+    }
+
+    s := stressRun{gen, brute, sol, judge, problemLimits(problem)}
+    failure, n, err := s.run(stressSeed, stressIterations, stressBudget, stressWorkers)
+    if err != nil {
+        return err
+    }
+    if failure == nil {
+        fmt.Printf("\r%s: %d generated tests passed\n", problem.Id(), n)
+        return nil
+    }
+
+    // keep the failing case as a sample test
+    testDir := session.TestDir(problem.Id())
+    i, err := workspace.AppendTest(testDir, workspace.Test{Input: failure.result.Input, Output: failure.result.Expected})
+    if err != nil {
+        return err
+    }
+    fmt.Printf("\r%s: seed %d failed after %d generated tests, saved as test %d\n", problem.Id(), failure.seed, n, i)
+
+    failure.result.Index = i
+    var cmp Comparator
+    if problem.CheckerSource == "" {
+        cmp, _ = parseComparator(problem.Checker)
+    }
+    printResults(problem, []TestResult{failure.result}, newDiffRenderer(noColor, fullDiff), cmp)
+
+    problem.Tests.Total = i + 1
+    session.SetProblem(problem)
+    return w.WriteSession(session)
+}
+
+var (
+    stressGen        string
+    stressBrute      string
+    stressSeed       int64
+    stressIterations int
+    stressBudget     time.Duration
+    stressWorkers    int
+)
+
+func init() {
+    stressCmd.Flags().StringVar(&stressGen, "gen", "", "generator source, run as: gen <seed> (default gen{ext})")
+    stressCmd.Flags().StringVar(&stressBrute, "brute", "", "brute force source (default brute{ext})")
+    stressCmd.Flags().Int64Var(&stressSeed, "seed", 1, "first generator seed")
+    stressCmd.Flags().IntVarP(&stressIterations, "iterations", "n", 1000, "maximum number of generated tests, 0 for no limit")
+    stressCmd.Flags().DurationVarP(&stressBudget, "time", "t", 0, "time budget, e.g. 30s, 0 for no limit")
+    stressCmd.Flags().IntVarP(&stressWorkers, "workers", "j", runtime.NumCPU(), "number of tests run in parallel")
+    stressCmd.Flags().BoolVar(&noColor, "no-color", false, "disable colored diff output")
+    stressCmd.Flags().BoolVar(&fullDiff, "full", false, "show the complete diff of a failing test")
+    rootCmd.AddCommand(stressCmd)
+}
+
+// programs and judge used by a stress test
+type stressRun struct {
+    gen     *program
+    brute   *program
+    sol     *program
+    checker Checker
+    limits  limits
+}
+
+// a generated test the solution failed
+type stressFailure struct {
+    seed   int64
+    result TestResult
+}
+
+// runs generated tests for seeds first, first+1... on workers goroutines until
+// a test fails, iterations tests ran or budget elapsed (zero means unlimited).
+// returns the failure with the smallest seed found, if any, and the number of tests run
+func (s stressRun) run(first int64, iterations int, budget time.Duration, workers int) (*stressFailure, int, error) {
+    if workers < 1 {
+        workers = 1
+    }
+    var deadline time.Time
+    if budget > 0 {
+        deadline = time.Now().Add(budget)
+    }
+
+    var (
+        mu      sync.Mutex
+        next    = first
+        done    int
+        failure *stressFailure
+        runErr  error
+    )
+    // hands out the next seed, !ok once the run should stop
+    nextSeed := func() (int64, bool) {
+        mu.Lock()
+        defer mu.Unlock()
+        stop := failure != nil || runErr != nil ||
+            (iterations > 0 && next-first >= int64(iterations)) ||
+            (!deadline.IsZero() && time.Now().After(deadline))
+        if stop {
+            return 0, false
+        }
+        seed := next
+        next++
+        return seed, true
+    }
+
+    var wg sync.WaitGroup
+    for w := 0; w < workers; w++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for {
+                seed, ok := nextSeed()
+                if !ok {
+                    return
+                }
+                result, err := s.test(seed)
+                mu.Lock()
+                done++
+                switch {
+                case err != nil:
+                    runErr = err
+                case result.Label != workspace.Accepted && (failure == nil || seed < failure.seed):
+                    failure = &stressFailure{seed, result}
+                }
+                fmt.Printf("\r%d generated tests run", done)
+                mu.Unlock()
+            }
+        }()
+    }
+    wg.Wait()
+    return failure, done, runErr
+}
+
+// generates the test for seed, runs brute force and solution and judges the output
+func (s stressRun) test(seed int64) (TestResult, error) {
+    arg := strconv.FormatInt(seed, 10)
+
+    var input, genErr bytes.Buffer
+    u, err := runLimited(s.gen, referenceLimits, strings.NewReader(""), &input, &genErr, arg)
+    if err != nil {
+        return TestResult{}, err
+    }
+    if u.label != workspace.NA {
+        return TestResult{}, fmt.Errorf("generator failed on seed %d: %s %s\n%s", seed, u.label, u.message, genErr.String())
+    }
+
+    var expected, bruteErr bytes.Buffer
+    u, err = runLimited(s.brute, referenceLimits, bytes.NewReader(input.Bytes()), &expected, &bruteErr)
+    if err != nil {
+        return TestResult{}, err
+    }
+    if u.label != workspace.NA {
+        return TestResult{}, fmt.Errorf("brute force failed on seed %d: %s %s\n%s", seed, u.label, u.message, bruteErr.String())
+    }
+
+    var actual, solErr bytes.Buffer
+    u, err = runLimited(s.sol, s.limits, bytes.NewReader(input.Bytes()), &actual, &solErr)
+    if err != nil {
+        return TestResult{}, err
+    }
+    result := TestResult{
+        Label:    u.label,
+        Input:    input.String(),
+        Expected: expected.String(),
+        Actual:   actual.String(),
+        Stderr:   solErr.String(),
+        Message:  u.message,
+        Elapsed:  u.elapsed,
+        Memory:   u.memory,
+    }
+    if result.Label == workspace.NA {
+        test := workspace.Test{Input: result.Input, Output: result.Expected}
+        result.Label, result.Message, err = s.checker.Check(test, result.Actual)
+        if err != nil {
+            return TestResult{}, err
+        }
+    }
+    return result, nil
+}
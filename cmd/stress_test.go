@@ -0,0 +1,78 @@
+package cmd
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+// stress run of sh scripts: the generator prints the seed, brute force
+// doubles it and sol is the given script
+func scriptStressRun(t *testing.T, gen, sol string) stressRun {
+    dir := t.TempDir()
+    writeScript(t, dir, "gen.sh", gen)
+    writeScript(t, dir, "brute.sh", `read n; echo $((n * 2))`)
+    writeScript(t, dir, "sol.sh", sol)
+    return stressRun{
+        gen:     &program{dir: dir, exec: "sh ./gen.sh"},
+        brute:   &program{dir: dir, exec: "sh ./brute.sh"},
+        sol:     &program{dir: dir, exec: "sh ./sol.sh"},
+        checker: comparatorChecker{exactComparator{}},
+        limits:  limits{time: 2 * time.Second, memory: 256 << 20},
+    }
+}
+
+func TestStressSmallestSeed(t *testing.T) {
+    // wrong on seeds 5, 8, 11... where 5 finishes last
+    s := scriptStressRun(t, `echo $1`, `read n
+[ $n = 5 ] && sleep 0.3
+if [ $((n % 3)) = 2 ] && [ $n -ge 5 ]; then echo 0; else echo $((n * 2)); fi`)
+    failure, _, err := s.run(1, 50, 0, 4)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if failure == nil || failure.seed != 5 {
+        t.Fatalf("failure %+v, want seed 5", failure)
+    }
+    if failure.result.Input != "5\n" || failure.result.Expected != "10\n" || failure.result.Actual != "0\n" {
+        t.Errorf("failing test %+v", failure.result)
+    }
+}
+
+func TestStressIterations(t *testing.T) {
+    s := scriptStressRun(t, `echo $1`, `read n; echo $((n * 2))`)
+    failure, n, err := s.run(1, 7, 0, 3)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if failure != nil || n != 7 {
+        t.Errorf("ran %d tests with failure %+v, want 7 passing", n, failure)
+    }
+}
+
+func TestStressBudget(t *testing.T) {
+    s := scriptStressRun(t, `echo $1`, `read n; sleep 0.05; echo $((n * 2))`)
+    start := time.Now()
+    failure, n, err := s.run(1, 0, 300*time.Millisecond, 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if failure != nil || n == 0 {
+        t.Errorf("ran %d tests with failure %+v", n, failure)
+    }
+    // the test running at the deadline finishes, no new one starts
+    if elapsed := time.Since(start); elapsed > 2*time.Second {
+        t.Errorf("ran for %v with a 300ms budget", elapsed)
+    }
+}
+
+func TestStressGeneratorFailure(t *testing.T) {
+    s := scriptStressRun(t, `[ $1 = 3 ] && { echo "bad seed" >&2; exit 1; }; echo $1`, `read n; echo $((n * 2))`)
+    failure, _, err := s.run(1, 10, 0, 1)
+    if err == nil || !strings.Contains(err.Error(), "generator failed on seed 3") || !strings.Contains(err.Error(), "bad seed") {
+        t.Errorf("error %v, want the generator failure", err)
+    }
+    if failure != nil {
+        t.Errorf("generator failure reported as failing test %+v", failure)
+    }
+}
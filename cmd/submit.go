@@ -5,9 +5,11 @@ import (
     "github.com/spf13/cobra"
 )
 
+// hidden until submitting is implemented
 var submitCmd = &cobra.Command{
     Use: "submit",
     Short: "",
+    Hidden: true,
     Run: func(cmd *cobra.Command, args []string) {
         fmt.Println("hello from Cobra!")
     },
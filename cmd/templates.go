@@ -0,0 +1,289 @@
+package cmd
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+
+    "github.com/pahyde/forces/workspace"
+    "github.com/spf13/cobra"
+)
+
+// forces templates init
+// forces templates list
+// forces templates add <path> [--name main] [--toolchain cpp20]
+// forces templates remove <name|path>
+// forces templates toolchains
+// forces templates choose-starter [name]   <- changes templates.json
+// forces templates use <name> [problem]    <- changes session.json
+var templatesCmd = &cobra.Command{
+    Use: "templates",
+    Short: "Manage solution templates",
+}
+
+var templatesInitCmd = &cobra.Command{
+    Use: "init",
+    Short: "Create templates.json with the default template",
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        w := openWorkspace()
+        if _, err := os.Stat(w.TemplatesPath()); err == nil && !forceInit {
+            log.Fatalf("%s already exists, use --force to reset it", w.TemplatesPath())
+        }
+        if _, err := w.InitTemplates(); err != nil {
+            log.Fatal(err)
+        }
+        fmt.Printf("initialized %s\n", w.TemplatesPath())
+    },
+}
+
+var templatesListCmd = &cobra.Command{
+    Use: "list",
+    Short: "List templates, the starter marked with *",
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        r := readTemplates(openWorkspace())
+        printTemplates(os.Stdout, r)
+    },
+}
+
+var templatesAddCmd = &cobra.Command{
+    Use: "add <path>",
+    Short: "Register a template file",
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        w := openWorkspace()
+        r := readTemplates(w)
+        path, err := filepath.Abs(args[0])
+        if err != nil {
+            log.Fatal(err)
+        }
+        name := templateName
+        if name == "" {
+            name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+        }
+        t, err := r.Add(workspace.Template{
+            Name:      workspace.TemplateName(name),
+            Path:      path,
+            Toolchain: workspace.ToolchainName(templateToolchain),
+        })
+        if err != nil {
+            log.Fatal(err)
+        }
+        if makeStarter {
+            r.Starter = t.Name
+        }
+        if err := w.WriteTemplates(r); err != nil {
+            log.Fatal(err)
+        }
+        fmt.Printf("added %s %s, built with %s\n", t.Name, t.Ext, t.Toolchain)
+    },
+}
+
+var templatesRemoveCmd = &cobra.Command{
+    Use: "remove <name|path>",
+    Short: "Unregister a template, leaving its file in place",
+    Args: cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        w := openWorkspace()
+        r := readTemplates(w)
+        name := workspace.TemplateName(args[0])
+        if path, err := filepath.Abs(args[0]); err == nil {
+            for _, t := range r.List {
+                if t.Path == path {
+                    name = t.Name
+                }
+            }
+        }
+        if err := r.Remove(name); err != nil {
+            log.Fatal(err)
+        }
+        if err := w.WriteTemplates(r); err != nil {
+            log.Fatal(err)
+        }
+        fmt.Printf("removed %s\n", name)
+    },
+}
+
+var templatesStarterCmd = &cobra.Command{
+    Use: "choose-starter [name]",
+    Short: "Choose the template new solutions start from",
+    Args: cobra.MaximumNArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        w := openWorkspace()
+        r := readTemplates(w)
+        var name workspace.TemplateName
+        if len(args) == 1 {
+            name = workspace.TemplateName(args[0])
+        } else {
+            picked, err := pickTemplate(r, os.Stdin, os.Stdout)
+            if err != nil {
+                log.Fatal(err)
+            }
+            name = picked
+        }
+        if err := r.SetStarter(name); err != nil {
+            log.Fatal(err)
+        }
+        if err := w.WriteTemplates(r); err != nil {
+            log.Fatal(err)
+        }
+        fmt.Printf("starter is %s\n", name)
+    },
+}
+
+var templatesUseCmd = &cobra.Command{
+    Use: "use <name> [problem]",
+    Short: "Switch a problem of the session to another template",
+    Long: `Switch a problem (default: most recently modified) to another template.
+A solution is generated from the template unless one with its extension exists.`,
+    Args: cobra.RangeArgs(1, 2),
+    Run: func(cmd *cobra.Command, args []string) {
+        w := openWorkspace()
+        r := readTemplates(w)
+        t, ok := r.GetTemplate(workspace.TemplateName(args[0]))
+        if !ok {
+            log.Fatalf("template %s not found", args[0])
+        }
+        session, err := w.ReadSession()
+        if err != nil {
+            log.Fatal(err)
+        }
+        problem, err := resolveProblem(session, args[1:])
+        if err != nil {
+            log.Fatal(err)
+        }
+        problem, err = w.UseTemplate(&session, problem, t)
+        if err != nil {
+            log.Fatal(err)
+        }
+        if err := w.WriteSession(session); err != nil {
+            log.Fatal(err)
+        }
+        fmt.Printf("%s uses %s: %s\n", problem.Id(), t.Name, filepath.Join(session.Path, problem.FileName))
+    },
+}
+
+var templatesToolchainsCmd = &cobra.Command{
+    Use: "toolchains",
+    Short: "List the toolchains templates can be built with",
+    Long: `List built-in toolchains and those defined under "Toolchains" in templates.json.
+Commands run in a scratch directory with placeholders {{source}}, {{binary}} and {{dir}}.`,
+    Args: cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        r := readTemplates(openWorkspace())
+        printToolchains(os.Stdout, r)
+    },
+}
+
+var (
+    forceInit         bool
+    templateName      string
+    templateToolchain string
+    makeStarter       bool
+)
+
+func init() {
+    templatesInitCmd.Flags().BoolVar(&forceInit, "force", false, "replace an existing templates.json")
+    templatesAddCmd.Flags().StringVar(&templateName, "name", "", "template name (default: file name without extension)")
+    templatesAddCmd.Flags().StringVar(&templateToolchain, "toolchain", "", "toolchain building the template, see forces templates toolchains (default: by extension)")
+    templatesAddCmd.Flags().BoolVar(&makeStarter, "starter", false, "make the template the starter")
+    templatesCmd.AddCommand(templatesInitCmd)
+    templatesCmd.AddCommand(templatesListCmd)
+    templatesCmd.AddCommand(templatesAddCmd)
+    templatesCmd.AddCommand(templatesRemoveCmd)
+    templatesCmd.AddCommand(templatesStarterCmd)
+    templatesCmd.AddCommand(templatesUseCmd)
+    templatesCmd.AddCommand(templatesToolchainsCmd)
+    rootCmd.AddCommand(templatesCmd)
+}
+
+func readTemplates(w *workspace.Workspace) workspace.TemplateRegistry {
+    r, err := w.ReadTemplates()
+    if err != nil {
+        log.Fatal(err)
+    }
+    return r
+}
+
+// one line per template:
+// * main .cpp  cpp17    path: ~/cp/templates/main.cpp
+func printTemplates(out io.Writer, r workspace.TemplateRegistry) {
+    width, toolWidth := 0, 0
+    for _, t := range r.List {
+        if len(t.Name) > width {
+            width = len(t.Name)
+        }
+        if len(t.Toolchain) > toolWidth {
+            toolWidth = len(t.Toolchain)
+        }
+    }
+    for _, t := range r.List {
+        mark := " "
+        if t.Name == r.Starter {
+            mark = "*"
+        }
+        fmt.Fprintf(out, "%s %-*s %-5s %-*s path: %s\n", mark, width, t.Name, t.Ext, toolWidth, t.Toolchain, t.Path)
+    }
+}
+
+// one block per toolchain, user toolchains first:
+// cpp17 .cpp
+//     compile: g++ -std=c++17 -O2 -o {{binary}} {{source}}
+//     execute: {{binary}}
+func printToolchains(out io.Writer, r workspace.TemplateRegistry) {
+    seen := make(map[workspace.ToolchainName]bool)
+    for _, c := range append(r.Toolchains, workspace.BuiltinToolchains()...) {
+        // user toolchains shadow built-in ones
+        if seen[c.Name] {
+            continue
+        }
+        seen[c.Name] = true
+        fmt.Fprintf(out, "%s %s\n", c.Name, c.Ext)
+        if c.Compile != "" {
+            fmt.Fprintf(out, "    compile: %s\n", c.Compile)
+        }
+        fmt.Fprintf(out, "    execute: %s\n", c.Execute)
+    }
+}
+
+// lists the templates numbered from 1 and reads a choice by number or name
+// from in, an empty line keeps the current starter
+func pickTemplate(r workspace.TemplateRegistry, in io.Reader, out io.Writer) (workspace.TemplateName, error) {
+    if len(r.List) == 0 {
+        return "", fmt.Errorf("no templates registered, add one with forces templates add")
+    }
+    for i, t := range r.List {
+        mark := " "
+        if t.Name == r.Starter {
+            mark = "*"
+        }
+        fmt.Fprintf(out, "%s %d) %s %s  path: %s\n", mark, i+1, t.Name, t.Ext, t.Path)
+    }
+    scanner := bufio.NewScanner(in)
+    for {
+        fmt.Fprintf(out, "starter [1-%d]: ", len(r.List))
+        if !scanner.Scan() {
+            if err := scanner.Err(); err != nil {
+                return "", err
+            }
+            return "", fmt.Errorf("no template chosen")
+        }
+        choice := strings.TrimSpace(scanner.Text())
+        if choice == "" {
+            return r.Starter, nil
+        }
+        if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(r.List) {
+            return r.List[n-1].Name, nil
+        }
+        if _, ok := r.GetTemplate(workspace.TemplateName(choice)); ok {
+            return workspace.TemplateName(choice), nil
+        }
+        fmt.Fprintf(out, "no template %q\n", choice)
+    }
+}
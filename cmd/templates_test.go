@@ -0,0 +1,57 @@
+package cmd
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+
+    "github.com/pahyde/forces/workspace"
+)
+
+func TestPickTemplate(t *testing.T) {
+    r := workspace.TemplateRegistry{
+        Starter: "main",
+        List: []workspace.Template{
+            {Name: "main", Path: "main.cpp", Ext: ".cpp"},
+            {Name: "py", Path: "sol.py", Ext: ".py"},
+            {Name: "2", Path: "two.cpp", Ext: ".cpp"},
+        },
+    }
+    cases := []struct {
+        name    string
+        input   string
+        want    workspace.TemplateName
+        retries int // "no template" answers before the choice
+        err     bool
+    }{
+        {"index", "3\n", "2", 0, false},
+        {"name", "py\n", "py", 0, false},
+        {"padded", "  py \n", "py", 0, false},
+        // numbers in range pick by position even if a template has that name
+        {"index over name", "2\n", "py", 0, false},
+        {"name looking like an index", "main\n", "main", 0, false},
+        {"empty keeps the starter", "\n", "main", 0, false},
+        {"out of range then valid", "0\n4\nfoo\n1\n", "main", 3, false},
+        {"no choice", "foo\n", "", 1, true},
+        {"no input", "", "", 0, true},
+    }
+    for _, c := range cases {
+        var out bytes.Buffer
+        got, err := pickTemplate(r, strings.NewReader(c.input), &out)
+        if (err != nil) != c.err || got != c.want {
+            t.Errorf("%s: got %q, %v, want %q", c.name, got, err, c.want)
+        }
+        if n := strings.Count(out.String(), "no template "); n != c.retries {
+            t.Errorf("%s: %d rejected choices, want %d:\n%s", c.name, n, c.retries, out.String())
+        }
+    }
+
+    var out bytes.Buffer
+    pickTemplate(r, strings.NewReader("\n"), &out)
+    if !strings.Contains(out.String(), "* 1) main .cpp") || !strings.Contains(out.String(), "  2) py .py") {
+        t.Errorf("listing doesn't number templates and mark the starter:\n%s", out.String())
+    }
+    if _, err := pickTemplate(workspace.TemplateRegistry{}, strings.NewReader("1\n"), &out); err == nil {
+        t.Error("picked from an empty registry")
+    }
+}
@@ -5,42 +5,188 @@ import (
     "os"
     "path/filepath"
     "log"
+    "github.com/pahyde/forces/workspace"
     "github.com/spf13/cobra"
 )
 
 // forces test A
 // forces test   <- tests most recently modified solution
+// forces test --watch   <- re-tests solutions as they're saved
 var testCmd = &cobra.Command{
-    Use: "test",
-    Short: "",
+    Use: "test [problem]",
+    Short: "Run a solution against its sample tests",
     Args: cobra.MaximumNArgs(1),
     Run: func(cmd *cobra.Command, args []string) {
-        configDir, err := os.UserConfigDir()
-        if err != nil {
-            log.Fatal(err)
+        w := openWorkspace()
+        if watchTests {
+            if err := watch(w, args); err != nil {
+                log.Fatal(err)
+            }
+            return
         }
-        appDir := filepath.Join(configDir, "forces")
-
-        // read session.json data
-        p := filepath.Join(appDir, "session.json")
-        var session Session
-        if err := readJSON(p, &session); err != nil {
+        if err := testProblem(w, args); err != nil {
             log.Fatal(err)
         }
+    },
+}
 
-        // read templates.json data
-        p = filepath.Join(appDir, "templates.json")
-        var registry TemplateRegistry
-        if err := readJSON(p, &registry); err != nil {
-            log.Fatal(err)
+// runs the solution of the problem named by args (default: most recently
+// modified) against its sample tests, prints the results and records the
+// verdict in session.json
+func testProblem(w *workspace.Workspace, args []string) error {
+    session, err := w.ReadSession()
+    if err != nil {
+        return err
+    }
+    registry, err := w.ReadTemplates()
+    if err != nil {
+        return err
+    }
+
+    problem, err := resolveProblem(session, args)
+    if err != nil {
+        return err
+    }
+
+    t, ok := registry.GetTemplate(problem.Template)
+    if !ok {
+        return fmt.Errorf("couldn't find template %s in templates list", problem.Template)
+    }
+    toolchain, err := registry.ToolchainFor(t)
+    if err != nil {
+        return err
+    }
+    toolchain = withPrecompiledHeader(w.AppDir, filepath.Join(session.Path, problem.FileName), toolchain)
+
+    // --checker overrides and replaces the problem's stored checker
+    if checker != "" {
+        problem.Checker       = checker
+        problem.CheckerSource = ""
+    }
+    // --checker-src registers a checker program for the problem
+    if checkerSrc != "" {
+        name, err := registerChecker(session.Path, problem, checkerSrc)
+        if err != nil {
+            return err
+        }
+        problem.CheckerSource = name
+    }
+    // --interactor registers an interactor and marks the problem interactive
+    if interactor != "" {
+        path, err := filepath.Abs(interactor)
+        if err != nil {
+            return err
         }
+        problem.Interactor  = path
+        problem.Interactive = true
+    }
 
-        fmt.Println(session)
-        fmt.Println(registry)
-    },
+    var results []TestResult
+    if problem.Interactive {
+        results, err = runInteractive(session.Path, problem, toolchain)
+    } else {
+        results, err = runSamples(session.Path, problem, registry, toolchain)
+    }
+    if err != nil {
+        return err
+    }
+    // checker programs get a plain line diff
+    var cmp Comparator
+    if problem.CheckerSource == "" {
+        cmp, _ = parseComparator(problem.Checker)
+    }
+    printResults(problem, results, newDiffRenderer(noColor, fullDiff), cmp)
+
+    // record verdict in session.json
+    problem.Tests = workspace.TestVerdict{Passed: countPassed(results), Total: len(results)}
+    session.SetProblem(problem)
+    return w.WriteSession(session)
 }
 
+// returns the problem named by args[0] if given,
+// otherwise the problem with the most recently modified solution
+func resolveProblem(s workspace.Session, args []string) (workspace.ProblemState, error) {
+    if len(args) == 0 {
+        return s.ProblemRecent()
+    }
+    p, ok := s.ProblemById(args[0])
+    if !ok {
+        return workspace.ProblemState{}, fmt.Errorf("problem %s not found in current session", args[0])
+    }
+    return p, nil
+}
+
+// runs the solution of problem p against its sample tests using the
+// problem's checker program or output comparator
+func runSamples(dir string, p workspace.ProblemState, r workspace.TemplateRegistry, c workspace.Toolchain) ([]TestResult, error) {
+    judge, err := problemChecker(dir, p, r, c)
+    if err != nil {
+        return nil, err
+    }
+    if c, ok := judge.(*programChecker); ok {
+        defer c.Close()
+    }
+    return runTests(dir, p, c, judge)
+}
+
+var (
+    checker    string
+    checkerSrc string
+    interactor string
+    noColor    bool
+    fullDiff   bool
+    watchTests bool
+)
+
 func init() {
+    testCmd.Flags().StringVar(&checker, "checker", "", "output checker: exact, lines, tokens, yesno, float[:eps], abs[:eps], rel[:eps]")
+    testCmd.Flags().StringVar(&checkerSrc, "checker-src", "", "register a testlib-style checker program for the problem")
+    testCmd.Flags().StringVar(&interactor, "interactor", "", "register an interactor binary and test the problem interactively")
+    testCmd.Flags().BoolVar(&noColor, "no-color", false, "disable colored diff output")
+    testCmd.Flags().BoolVar(&fullDiff, "full", false, "show complete diffs of failing tests without truncation")
+    testCmd.Flags().BoolVarP(&watchTests, "watch", "w", false, "re-run the samples whenever a solution is saved")
     rootCmd.AddCommand(testCmd)
 }
 
+// returns the checker program registered for problem p, if any,
+// otherwise its output comparator. Checker programs are compiled with the
+// toolchain for their extension, falling back to the solution toolchain c.
+func problemChecker(dir string, p workspace.ProblemState, r workspace.TemplateRegistry, c workspace.Toolchain) (Checker, error) {
+    if p.CheckerSource == "" {
+        cmp, err := parseComparator(p.Checker)
+        if err != nil {
+            return nil, err
+        }
+        return comparatorChecker{cmp}, nil
+    }
+    path := filepath.Join(dir, "tests", p.Id(), p.CheckerSource)
+    if toolchain, ok := r.ToolchainForExt(filepath.Ext(path)); ok {
+        c = toolchain
+    }
+    return newProgramChecker(path, c, buildDir(dir))
+}
+
+// prints a line per test followed by details of each failure: the input,
+// then a diff of the expected and actual output (or the interaction transcript)
+func printResults(p workspace.ProblemState, results []TestResult, r diffRenderer, cmp Comparator) {
+    for _, res := range results {
+        fmt.Printf("%s test %d: %s (%dms, %dMB)\n", p.Id(), res.Index, res.Label, res.Elapsed.Milliseconds(), res.Memory>>20)
+        if res.Label == workspace.Accepted {
+            continue
+        }
+        if res.Message != "" {
+            fmt.Println(res.Message)
+        }
+        fmt.Printf("input:\n%s\n", res.Input)
+        switch {
+        case p.Interactive:
+            fmt.Printf("transcript:\n%s\n", res.Actual)
+        case res.Label == workspace.WrongAnswer:
+            r.render(os.Stdout, res.Expected, res.Actual, cmp)
+        }
+        if res.Stderr != "" {
+            fmt.Printf("stderr:\n%s\n", res.Stderr)
+        }
+    }
+    fmt.Printf("passed %d/%d\n", countPassed(results), len(results))
+}
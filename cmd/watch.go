@@ -0,0 +1,91 @@
+package cmd
+
+import (
+    "fmt"
+    "path/filepath"
+    "time"
+
+    "github.com/fsnotify/fsnotify"
+    "github.com/pahyde/forces/workspace"
+)
+
+// editors save in bursts of writes (or write, rename, chmod), so a problem
+// is tested once its solution has been quiet for this long
+const watchDebounce = 150 * time.Millisecond
+
+// tests the problem named by args (default: most recently modified) and
+// then watches the session dir, re-testing whichever solution is saved
+// (only the named one if given) after clearing the screen. Test failures
+// and compile errors are printed and watching goes on.
+func watch(w *workspace.Workspace, args []string) error {
+    session, err := w.ReadSession()
+    if err != nil {
+        return err
+    }
+    problem, err := resolveProblem(session, args)
+    if err != nil {
+        return err
+    }
+
+    watcher, err := fsnotify.NewWatcher()
+    if err != nil {
+        return err
+    }
+    defer watcher.Close()
+    if err := watcher.Add(session.Path); err != nil {
+        return err
+    }
+
+    run := func(id string) {
+        fmt.Print("\033[H\033[2J")
+        fmt.Printf("%s  %s\n\n", id, time.Now().Format("15:04:05"))
+        if err := testProblem(w, []string{id}); err != nil {
+            fmt.Println(err)
+        }
+        fmt.Printf("\nwatching %s for changes, ctrl-c to stop\n", session.Path)
+    }
+    run(problem.Id())
+
+    debounce := time.NewTimer(watchDebounce)
+    debounce.Stop()
+    pending := ""
+    for {
+        select {
+        case event, ok := <-watcher.Events:
+            if !ok {
+                return nil
+            }
+            if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+                continue
+            }
+            // re-read the session, problems may have switched templates
+            session, err := w.ReadSession()
+            if err != nil {
+                return err
+            }
+            saved, ok := problemByFile(session, filepath.Base(event.Name))
+            if !ok || (len(args) > 0 && saved.Id() != problem.Id()) {
+                continue
+            }
+            pending = saved.Id()
+            debounce.Reset(watchDebounce)
+        case <-debounce.C:
+            run(pending)
+        case err, ok := <-watcher.Errors:
+            if !ok {
+                return nil
+            }
+            return err
+        }
+    }
+}
+
+// returns the problem of session s whose solution is the file name
+func problemByFile(s workspace.Session, name string) (workspace.ProblemState, bool) {
+    for _, p := range s.Problems {
+        if p.FileName == name {
+            return p, true
+        }
+    }
+    return workspace.ProblemState{}, false
+}
@@ -2,10 +2,16 @@ module github.com/pahyde/forces
 
 go 1.19
 
-require golang.org/x/net v0.0.0-20220812174116-3211cb980234
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/peterh/liner v1.2.2
+	github.com/spf13/cobra v1.5.0
+	golang.org/x/net v0.0.0-20220812174116-3211cb980234
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.0.1 // indirect
-	github.com/spf13/cobra v1.5.0 // indirect
+	github.com/mattn/go-runewidth v0.0.3 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.4.0 // indirect
 )
@@ -0,0 +1,217 @@
+// Package query finds nodes of an html parse tree with a small subset of
+// css selectors:
+//   div            tag
+//   .sample-test   class token, matching class="sample-test foo" too
+//   #pageContent   id
+//   div.input.x    any combination of the above
+//   a b            b descendant of a
+//   a > b          b child of a
+// A selector may start with ">" to only match children of the node
+// searched from, e.g. "> td" for the cells of a row but not nested tables.
+package query
+
+import (
+    "fmt"
+    "strings"
+    "golang.org/x/net/html"
+)
+
+// compiled selector
+type Selector struct {
+    steps []step
+}
+
+// compound selector and how it relates to the previous one
+type step struct {
+    child   bool // direct child of the previous step, otherwise descendant
+    tag     string
+    id      string
+    classes []string
+}
+
+// parses a selector
+func Compile(sel string) (*Selector, error) {
+    s := &Selector{}
+    child := false
+    fields := strings.Fields(strings.ReplaceAll(sel, ">", " > "))
+    for _, f := range fields {
+        if f == ">" {
+            if child {
+                return nil, fmt.Errorf("invalid selector %q: repeated >", sel)
+            }
+            child = true
+            continue
+        }
+        st, err := parseStep(f)
+        if err != nil {
+            return nil, fmt.Errorf("invalid selector %q: %v", sel, err)
+        }
+        st.child = child
+        child = false
+        s.steps = append(s.steps, st)
+    }
+    if len(s.steps) == 0 {
+        return nil, fmt.Errorf("invalid selector %q: empty", sel)
+    }
+    if child {
+        return nil, fmt.Errorf("invalid selector %q: trailing >", sel)
+    }
+    return s, nil
+}
+
+// like Compile but panics on invalid selectors, for selector literals
+func MustCompile(sel string) *Selector {
+    s, err := Compile(sel)
+    if err != nil {
+        panic(err)
+    }
+    return s
+}
+
+// parses a compound selector like div.input#id0
+func parseStep(f string) (step, error) {
+    var st step
+    i := strings.IndexAny(f, ".#")
+    if i < 0 {
+        i = len(f)
+    }
+    st.tag = strings.ToLower(f[:i])
+    for i < len(f) {
+        kind := f[i]
+        j := strings.IndexAny(f[i+1:], ".#")
+        if j < 0 {
+            j = len(f)
+        } else {
+            j += i + 1
+        }
+        name := f[i+1 : j]
+        if name == "" {
+            return step{}, fmt.Errorf("empty name after %c", kind)
+        }
+        if kind == '.' {
+            st.classes = append(st.classes, name)
+        } else {
+            st.id = name
+        }
+        i = j
+    }
+    return st, nil
+}
+
+// returns the first element below root, in document order, matching s
+// or nil if there is none
+func (s *Selector) Query(root *html.Node) *html.Node {
+    var found *html.Node
+    s.walk(root, root, func(n *html.Node) bool {
+        found = n
+        return false
+    })
+    return found
+}
+
+// returns every element below root matching s in document order
+func (s *Selector) QueryAll(root *html.Node) []*html.Node {
+    found := make([]*html.Node, 0)
+    s.walk(root, root, func(n *html.Node) bool {
+        found = append(found, n)
+        return true
+    })
+    return found
+}
+
+// calls visit with matching descendants of n until it returns false
+// matches are scoped to root
+func (s *Selector) walk(root, n *html.Node, visit func(*html.Node) bool) bool {
+    for c := n.FirstChild; c != nil; c = c.NextSibling {
+        if s.match(root, c, len(s.steps)-1) && !visit(c) {
+            return false
+        }
+        if !s.walk(root, c, visit) {
+            return false
+        }
+    }
+    return true
+}
+
+// reports whether n matches steps[:i+1] with ancestors no higher than
+// below root
+func (s *Selector) match(root, n *html.Node, i int) bool {
+    st := s.steps[i]
+    if !st.matches(n) {
+        return false
+    }
+    if i == 0 {
+        return !st.child || n.Parent == root
+    }
+    if st.child {
+        return n.Parent != root && s.match(root, n.Parent, i-1)
+    }
+    for p := n.Parent; p != nil && p != root; p = p.Parent {
+        if s.match(root, p, i-1) {
+            return true
+        }
+    }
+    return false
+}
+
+func (st step) matches(n *html.Node) bool {
+    if n.Type != html.ElementNode {
+        return false
+    }
+    if st.tag != "" && st.tag != "*" && n.Data != st.tag {
+        return false
+    }
+    if st.id != "" && Attr(n, "id") != st.id {
+        return false
+    }
+    for _, c := range st.classes {
+        if !HasClass(n, c) {
+            return false
+        }
+    }
+    return true
+}
+
+// returns the first element below root matching selector sel
+// panics if sel is invalid
+func Query(root *html.Node, sel string) *html.Node {
+    return MustCompile(sel).Query(root)
+}
+
+// returns every element below root matching selector sel
+// panics if sel is invalid
+func QueryAll(root *html.Node, sel string) []*html.Node {
+    return MustCompile(sel).QueryAll(root)
+}
+
+// reports whether the class attribute of n contains the token class
+func HasClass(n *html.Node, class string) bool {
+    for _, c := range strings.Fields(Attr(n, "class")) {
+        if c == class {
+            return true
+        }
+    }
+    return false
+}
+
+// returns the value of attribute k of n, or "" if unset
+func Attr(n *html.Node, k string) string {
+    for _, a := range n.Attr {
+        if a.Key == k {
+            return a.Val
+        }
+    }
+    return ""
+}
+
+// returns the concatenated text of every text node in n
+func Text(n *html.Node) string {
+    if n.Type == html.TextNode {
+        return n.Data
+    }
+    var b strings.Builder
+    for c := n.FirstChild; c != nil; c = c.NextSibling {
+        b.WriteString(Text(c))
+    }
+    return b.String()
+}
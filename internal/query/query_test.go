@@ -0,0 +1,105 @@
+package query
+
+import (
+    "strings"
+    "testing"
+    "golang.org/x/net/html"
+)
+
+const page = `<div id="body">
+<div class="sample-tests">
+    <div class="sample-test foo">
+        <div class="input"><div class="title">Input</div><pre id="in0">1</pre></div>
+        <div class="output"><div class="title">Output</div><pre id="out0">2</pre></div>
+    </div>
+</div>
+<table class="problems">
+    <tr><td class="id">A</td><td><table><tr><td class="id">nested</td></tr></table></td></tr>
+    <tr><td class="id">B</td></tr>
+</table>
+</div>`
+
+func parse(t *testing.T) *html.Node {
+    t.Helper()
+    doc, err := html.Parse(strings.NewReader(page))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return doc
+}
+
+// ids, or text when there's no id, of every match
+func describe(nodes []*html.Node) string {
+    out := make([]string, 0, len(nodes))
+    for _, n := range nodes {
+        if id := Attr(n, "id"); id != "" {
+            out = append(out, id)
+        } else {
+            out = append(out, strings.TrimSpace(Text(n)))
+        }
+    }
+    return strings.Join(out, ",")
+}
+
+func TestQueryAll(t *testing.T) {
+    doc := parse(t)
+    cases := []struct {
+        sel  string
+        want string
+    }{
+        {"pre", "in0,out0"},
+        {"#out0", "out0"},
+        {"pre#in0", "in0"},
+        {".sample-test", "Input1 Output2"},
+        {"div.sample-test.foo .output pre", "out0"},
+        {".input > pre", "in0"},
+        {".sample-tests > pre", ""},
+        {".sample-tests > .sample-test > .input > .title", "Input"},
+        {"table.problems td.id", "A,nested,B"},
+        {"table.problems > tbody > tr > td.id", "A,B"},
+        {".missing", ""},
+    }
+    for _, c := range cases {
+        got := describe(QueryAll(doc, c.sel))
+        if strings.Join(strings.Fields(got), " ") != strings.Join(strings.Fields(c.want), " ") {
+            t.Errorf("QueryAll(%q) = %q, want %q", c.sel, got, c.want)
+        }
+    }
+}
+
+func TestQueryScope(t *testing.T) {
+    doc := parse(t)
+    row := Query(doc, "table.problems tr")
+    if row == nil {
+        t.Fatal("row not found")
+    }
+    if got := describe(QueryAll(row, "> td.id")); got != "A" {
+        t.Errorf(`QueryAll(row, "> td.id") = %q, want "A"`, got)
+    }
+    if got := describe(QueryAll(row, "td.id")); got != "A,nested" {
+        t.Errorf(`QueryAll(row, "td.id") = %q, want "A,nested"`, got)
+    }
+    // ancestors above the searched node don't count
+    input := Query(doc, ".input")
+    if got := Query(input, ".sample-test pre"); got != nil {
+        t.Errorf(`Query(input, ".sample-test pre") = %v, want nil`, got)
+    }
+}
+
+func TestQueryFirst(t *testing.T) {
+    doc := parse(t)
+    if n := Query(doc, "pre"); n == nil || Attr(n, "id") != "in0" {
+        t.Errorf(`Query("pre") = %v, want #in0`, n)
+    }
+    if n := Query(doc, "span"); n != nil {
+        t.Errorf(`Query("span") = %v, want nil`, n)
+    }
+}
+
+func TestCompileErrors(t *testing.T) {
+    for _, sel := range []string{"", "  ", "a >", "a > > b", "div.", "#"} {
+        if _, err := Compile(sel); err == nil {
+            t.Errorf("Compile(%q) succeeded, want error", sel)
+        }
+    }
+}
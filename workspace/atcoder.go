@@ -0,0 +1,249 @@
+package workspace
+
+import (
+    "fmt"
+    "net/url"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+    "golang.org/x/net/html"
+
+    "github.com/pahyde/forces/internal/query"
+)
+
+// atcoder url used unless configured otherwise
+const DefaultAtCoderUrl = "https://atcoder.jp"
+
+// AtCoder judge
+// Problem ids are task letters (A, B, Ex...) like codeforces problem ids,
+// task pages are named after the contest and letter (abc300_a).
+type AtCoder struct {
+    BaseUrl string
+}
+
+var (
+    // /contests/abc300, /contests/abc300/tasks, /contests/abc300/tasks/abc300_a
+    atcoderPath = regexp.MustCompile(`^/contests/([\w-]+)(?:/tasks(?:/(\w+))?)?/?$`)
+    // Time Limit: 2 sec / Memory Limit: 1024 MB
+    // 実行時間制限: 2 sec / メモリ制限: 1024 MB
+    atcoderLimits = regexp.MustCompile(`([\d.]+)\s*sec\s*/[^:]*:\s*(\d+)\s*([KM])i?B`)
+)
+
+func (AtCoder) Name() string {
+    return AtCoderJudge
+}
+
+// parses a contest given as an id (abc300) or a contest, tasks or task url
+// https://atcoder.jp/contests/abc300/tasks/abc300_a
+func (AtCoder) ParseSource(s string) (Source, string, error) {
+    if !strings.Contains(s, "://") {
+        if !sourceIdPattern.MatchString(s) {
+            return Source{}, "", fmt.Errorf("invalid atcoder contest id %q", s)
+        }
+        return Source{Judge: AtCoderJudge, Kind: ContestSource, Contest: s}, "", nil
+    }
+    u, err := url.Parse(s)
+    if err != nil {
+        return Source{}, "", err
+    }
+    m := atcoderPath.FindStringSubmatch(u.Path)
+    if m == nil {
+        return Source{}, "", fmt.Errorf("unrecognized atcoder url %q", s)
+    }
+    src := Source{Judge: AtCoderJudge, Kind: ContestSource, Contest: m[1]}
+    problemId := ""
+    if task := m[2]; task != "" {
+        // abc300_a -> A
+        problemId = strings.ToUpper(task[strings.LastIndex(task, "_")+1:])
+    }
+    return src, problemId, nil
+}
+
+// task names start with the contest id with dashes as underscores
+func atcoderTaskPrefix(contest string) string {
+    return strings.ReplaceAll(contest, "-", "_")
+}
+
+func (a AtCoder) ContestUrl(s Source) string {
+    return fmt.Sprintf("%s/contests/%s/tasks", a.BaseUrl, s.Contest)
+}
+
+// task pages of contests listed with ParseContest carry their own url,
+// this guesses the usual {contest}_{letter} naming
+func (a AtCoder) ProblemUrl(s Source, problemId string) string {
+    task := atcoderTaskPrefix(s.Contest) + "_" + strings.ToLower(problemId)
+    return fmt.Sprintf("%s/contests/%s/tasks/%s", a.BaseUrl, s.Contest, task)
+}
+
+// parses the task list of a contest tasks page:
+// <tr>
+//     <td class="text-center no-break"><a href="/contests/abc300/tasks/abc300_a">A</a></td>
+//     <td><a href="/contests/abc300/tasks/abc300_a">N-choice question</a></td>
+//     <td class="text-right">2 sec</td>
+//     <td class="text-right">1024 MB</td>
+//     ...
+// </tr>
+func (a AtCoder) ParseContest(page *html.Node) ([]ProblemSummary, error) {
+    problems := make([]ProblemSummary, 0)
+    seen := make(map[string]bool)
+    for i, tr := range query.QueryAll(page, "table tbody tr") {
+        cells := query.QueryAll(tr, "> td")
+        if len(cells) < 2 {
+            continue
+        }
+        link := query.Query(cells[0], "a")
+        if link == nil || !strings.Contains(query.Attr(link, "href"), "/tasks/") {
+            continue
+        }
+        id := strings.TrimSpace(query.Text(link))
+        if !problemIdPattern.MatchString(id) {
+            return nil, &ParseError{Page: "tasks", Row: i + 1, Msg: fmt.Sprintf("invalid task id %q", id)}
+        }
+        if seen[id] {
+            return nil, &ParseError{Page: "tasks", Row: i + 1, Msg: fmt.Sprintf("duplicate task id %q", id)}
+        }
+        seen[id] = true
+        problems = append(problems, ProblemSummary{
+            Id:     id,
+            Name:   strings.TrimSpace(collapseSpace(query.Text(cells[1]))),
+            Solved: -1,
+            Url:    a.BaseUrl + query.Attr(link, "href"),
+        })
+    }
+    if len(problems) == 0 {
+        return nil, &ParseError{Page: "tasks", Msg: "task list is empty"}
+    }
+    return problems, nil
+}
+
+// parses a task page:
+// <span class="h2">A - N-choice question <a>Editorial</a></span>
+// <p>Time Limit: 2 sec / Memory Limit: 1024 MB</p>
+// <div id="task-statement">
+//     <span class="lang"><span class="lang-ja">...</span><span class="lang-en">
+//         <div class="part"><section><h3>Problem Statement</h3>...</section></div>
+//         ...
+//         <div class="part"><section><h3>Sample Input 1</h3><pre>...</pre></section></div>
+//         <div class="part"><section><h3>Sample Output 1</h3><pre>...</pre></section></div>
+//     </span></span>
+// </div>
+// The english statement is preferred, older tasks only have a japanese one.
+func (a AtCoder) ParseProblem(page *html.Node) (Problem, error) {
+    title := query.Query(page, "span.h2")
+    if title == nil {
+        return Problem{}, fmt.Errorf("problem name not found")
+    }
+    // the title is followed by an editorial link
+    var name string
+    for c := title.FirstChild; c != nil; c = c.NextSibling {
+        if c.Type == html.TextNode {
+            name += c.Data
+        }
+    }
+    p := Problem{Name: strings.TrimSpace(collapseSpace(name))}
+
+    // limits are in the first paragraph mentioning them
+    for _, para := range query.QueryAll(page, "p") {
+        m := atcoderLimits.FindStringSubmatch(query.Text(para))
+        if m == nil {
+            continue
+        }
+        seconds, _ := strconv.ParseFloat(m[1], 64)
+        size, _ := strconv.ParseInt(m[2], 10, 64)
+        p.TimeLimit = time.Duration(seconds * float64(time.Second))
+        if m[3] == "K" {
+            p.MemoryLimit = size << 10
+        } else {
+            p.MemoryLimit = size << 20
+        }
+        break
+    }
+    if p.TimeLimit == 0 {
+        return Problem{}, fmt.Errorf("time and memory limits not found")
+    }
+
+    statement := query.Query(page, "#task-statement .lang-en")
+    if statement == nil {
+        statement = query.Query(page, "#task-statement")
+    }
+    if statement == nil {
+        return Problem{}, fmt.Errorf("<div id=\"task-statement\"> not found")
+    }
+
+    inputs  := make([]string, 0)
+    outputs := make([]string, 0)
+    ioSection := ""
+    for _, section := range query.QueryAll(statement, "section") {
+        h3 := query.Query(section, "h3")
+        if h3 == nil {
+            continue
+        }
+        heading := strings.TrimSpace(query.Text(h3))
+        body := atcoderSection(section)
+        pre := query.Query(section, "pre")
+        switch {
+        case strings.HasPrefix(heading, "Sample Input"), strings.HasPrefix(heading, "入力例"):
+            if pre != nil {
+                inputs = append(inputs, sampleText(pre))
+            }
+        case strings.HasPrefix(heading, "Sample Output"), strings.HasPrefix(heading, "出力例"):
+            if pre != nil {
+                outputs = append(outputs, sampleText(pre))
+            }
+        case heading == "Problem Statement", heading == "問題文":
+            p.Statement.Legend = body
+        case heading == "Constraints", heading == "制約":
+            p.Statement.Constraints = body
+        case heading == "Input", heading == "入力":
+            p.Statement.Input = body
+        case heading == "Output", heading == "出力":
+            p.Statement.Output = body
+        case heading == "Interaction", heading == "インタラクション":
+            p.Statement.Interaction = body
+            p.Interactive = true
+        case heading == "Input and Output", heading == "入出力":
+            ioSection = body
+        }
+    }
+    // "入出力" heads the interaction of interactive tasks but also plain
+    // combined input/output sections
+    if ioSection != "" {
+        if p.Interactive || atcoderInteractive(statement) {
+            p.Statement.Interaction = ioSection
+            p.Interactive = true
+        } else if p.Statement.Input == "" {
+            p.Statement.Input = ioSection
+        }
+    }
+    if len(inputs) != len(outputs) {
+        return Problem{}, fmt.Errorf("found %d sample inputs but %d outputs", len(inputs), len(outputs))
+    }
+    for i := range inputs {
+        p.Tests = append(p.Tests, Test{Input: inputs[i], Output: outputs[i]})
+    }
+    if len(p.Tests) == 0 && !p.Interactive {
+        return Problem{}, fmt.Errorf("no sample tests found")
+    }
+    return p, nil
+}
+
+// reports whether an atcoder task statement says the task is interactive
+// ("This is an interactive task", "この問題はインタラクティブな問題です")
+func atcoderInteractive(statement *html.Node) bool {
+    text := strings.ToLower(query.Text(statement))
+    return strings.Contains(text, "interactive task") || strings.Contains(text, "interactive problem") ||
+        strings.Contains(text, "インタラクティブ")
+}
+
+// markdown of a statement section without its heading
+func atcoderSection(section *html.Node) string {
+    var b strings.Builder
+    for c := section.FirstChild; c != nil; c = c.NextSibling {
+        if c.Type == html.ElementNode && c.Data == "h3" {
+            continue
+        }
+        writeMarkdown(&b, c)
+    }
+    return tidyMarkdown(b.String())
+}
@@ -0,0 +1,152 @@
+package workspace
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+)
+
+// age after which cached pages are revalidated by default
+const DefaultCacheTTL = 24 * time.Hour
+
+// Cache stores fetched pages on disk keyed by url:
+//   Dir/{sha256 of url}.json  CacheEntry
+//   Dir/{sha256 of url}.html  page body
+// Entries younger than TTL are served without touching the network,
+// older ones are revalidated with their ETag/Last-Modified. Refresh
+// ignores cached entries but still stores fresh downloads.
+type Cache struct {
+    Dir     string
+    TTL     time.Duration
+    Refresh bool
+}
+
+// metadata of a cached page
+type CacheEntry struct {
+    Url          string
+    ETag         string
+    LastModified string
+    Fetched      time.Time // last download or revalidation
+    Size         int64
+}
+
+// Store cached pages at os dependent cache directory
+// (e.g. .cache/forces for linux).
+func DefaultCacheDir() (string, error) {
+    cacheDir, err := os.UserCacheDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(cacheDir, "forces"), nil
+}
+
+// path of the files of url without extension
+func (c *Cache) path(url string) string {
+    sum := sha256.Sum256([]byte(url))
+    return filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+}
+
+// true if e can be served without revalidation
+func (c *Cache) Fresh(e CacheEntry) bool {
+    return !c.Refresh && time.Since(e.Fetched) < c.TTL
+}
+
+// returns the cached entry and body of url, if any
+func (c *Cache) Get(url string) (CacheEntry, []byte, bool) {
+    var e CacheEntry
+    p := c.path(url)
+    if err := readJSON(p+".json", &e); err != nil || e.Url != url {
+        return CacheEntry{}, nil, false
+    }
+    body, err := os.ReadFile(p + ".html")
+    if err != nil {
+        return CacheEntry{}, nil, false
+    }
+    return e, body, true
+}
+
+// stores body as the cached page of e.Url
+func (c *Cache) Put(e CacheEntry, body []byte) error {
+    if err := os.MkdirAll(c.Dir, 0700); err != nil {
+        return err
+    }
+    e.Size = int64(len(body))
+    p := c.path(e.Url)
+    // the body goes first so a readable entry always has one
+    if err := writeFileAtomic(p+".html", body); err != nil {
+        return err
+    }
+    dat, err := json.Marshal(&e)
+    if err != nil {
+        return err
+    }
+    return writeFileAtomic(p+".json", dat)
+}
+
+// returns every cached entry, most recently fetched first
+func (c *Cache) List() ([]CacheEntry, error) {
+    paths, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+    if err != nil {
+        return nil, err
+    }
+    entries := make([]CacheEntry, 0, len(paths))
+    for _, p := range paths {
+        var e CacheEntry
+        if err := readJSON(p, &e); err != nil {
+            continue
+        }
+        entries = append(entries, e)
+    }
+    sort.Slice(entries, func(i, j int) bool {
+        return entries[i].Fetched.After(entries[j].Fetched)
+    })
+    return entries, nil
+}
+
+// removes every cached page
+func (c *Cache) Clear() error {
+    dir, err := os.ReadDir(c.Dir)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    for _, f := range dir {
+        name := f.Name()
+        if strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".html") || strings.HasPrefix(name, ".tmp-") {
+            if err := os.Remove(filepath.Join(c.Dir, name)); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// writes data to a temporary file renamed over path, so concurrent
+// readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+    f, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+    if err != nil {
+        return err
+    }
+    if _, err := f.Write(data); err != nil {
+        f.Close()
+        os.Remove(f.Name())
+        return err
+    }
+    if err := f.Close(); err != nil {
+        os.Remove(f.Name())
+        return err
+    }
+    if err := os.Rename(f.Name(), path); err != nil {
+        os.Remove(f.Name())
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,192 @@
+package workspace
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/pahyde/forces/internal/query"
+)
+
+// stub site serving a page tagged with its version, answering requests
+// revalidating the current ETag with 304 Not Modified
+type stubSite struct {
+    version     int32
+    requests    int32
+    revalidated int32
+}
+
+func (s *stubSite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    atomic.AddInt32(&s.requests, 1)
+    etag := fmt.Sprintf(`"v%d"`, atomic.LoadInt32(&s.version))
+    if r.Header.Get("If-None-Match") == etag {
+        atomic.AddInt32(&s.revalidated, 1)
+        w.WriteHeader(http.StatusNotModified)
+        return
+    }
+    w.Header().Set("ETag", etag)
+    fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", etag)
+}
+
+// fetches url returning the text of the page's paragraph
+func fetchText(t *testing.T, f Fetcher, url string) string {
+    root, err := f.Fetch(url)
+    if err != nil {
+        t.Fatal(err)
+    }
+    p := query.Query(root, "p")
+    if p == nil {
+        t.Fatalf("no paragraph in page of %s", url)
+    }
+    return query.Text(p)
+}
+
+func TestCacheHitMiss(t *testing.T) {
+    site := &stubSite{}
+    srv := httptest.NewServer(site)
+    defer srv.Close()
+
+    cache := &Cache{Dir: t.TempDir(), TTL: time.Hour}
+    f := HTTPFetcher{Cache: cache}
+    url := srv.URL + "/contest/1336"
+
+    if _, _, ok := cache.Get(url); ok {
+        t.Fatal("empty cache has an entry")
+    }
+    // miss: downloaded and stored
+    if got := fetchText(t, f, url); got != `"v0"` {
+        t.Errorf("first fetch %q", got)
+    }
+    e, body, ok := cache.Get(url)
+    if !ok || e.ETag != `"v0"` || e.Size != int64(len(body)) {
+        t.Errorf("stored entry %+v, %d bytes, %v", e, len(body), ok)
+    }
+
+    // hit: served without a request even though the page changed
+    atomic.StoreInt32(&site.version, 1)
+    if got := fetchText(t, f, url); got != `"v0"` {
+        t.Errorf("cached fetch %q", got)
+    }
+    if n := atomic.LoadInt32(&site.requests); n != 1 {
+        t.Errorf("%d requests, want 1", n)
+    }
+
+    // another url misses
+    fetchText(t, f, srv.URL + "/contest/1337")
+    if n := atomic.LoadInt32(&site.requests); n != 2 {
+        t.Errorf("%d requests, want 2", n)
+    }
+}
+
+func TestCacheExpiry(t *testing.T) {
+    site := &stubSite{}
+    srv := httptest.NewServer(site)
+    defer srv.Close()
+
+    cache := &Cache{Dir: t.TempDir(), TTL: time.Hour}
+    f := HTTPFetcher{Cache: cache}
+    url := srv.URL + "/contest/1336"
+    fetchText(t, f, url)
+
+    // expired but unchanged: revalidated with a 304, refreshing Fetched
+    e, body, _ := cache.Get(url)
+    e.Fetched = time.Now().Add(-2 * time.Hour)
+    if err := cache.Put(e, body); err != nil {
+        t.Fatal(err)
+    }
+    if cache.Fresh(e) {
+        t.Error("entry older than the ttl is fresh")
+    }
+    if got := fetchText(t, f, url); got != `"v0"` {
+        t.Errorf("revalidated fetch %q", got)
+    }
+    if n := atomic.LoadInt32(&site.revalidated); n != 1 {
+        t.Errorf("%d revalidations, want 1", n)
+    }
+    if e, _, _ := cache.Get(url); !cache.Fresh(e) {
+        t.Errorf("revalidated entry isn't fresh: fetched %v", e.Fetched)
+    }
+
+    // expired and changed: downloaded again
+    atomic.StoreInt32(&site.version, 1)
+    e.Fetched = time.Now().Add(-2 * time.Hour)
+    cache.Put(e, body)
+    if got := fetchText(t, f, url); got != `"v1"` {
+        t.Errorf("fetch of changed page %q", got)
+    }
+    if e, _, _ := cache.Get(url); e.ETag != `"v1"` {
+        t.Errorf("cache kept etag %s", e.ETag)
+    }
+}
+
+func TestCacheStaleOffline(t *testing.T) {
+    site := &stubSite{}
+    srv := httptest.NewServer(site)
+    cache := &Cache{Dir: t.TempDir(), TTL: time.Hour}
+    f := HTTPFetcher{Cache: cache}
+    url := srv.URL + "/contest/1336"
+    fetchText(t, f, url)
+    srv.Close()
+
+    e, body, _ := cache.Get(url)
+    e.Fetched = time.Now().Add(-2 * time.Hour)
+    cache.Put(e, body)
+    if got := fetchText(t, f, url); got != `"v0"` {
+        t.Errorf("stale fetch while offline %q", got)
+    }
+}
+
+func TestCacheRefresh(t *testing.T) {
+    site := &stubSite{}
+    srv := httptest.NewServer(site)
+    defer srv.Close()
+
+    cache := &Cache{Dir: t.TempDir(), TTL: time.Hour}
+    f := HTTPFetcher{Cache: cache}
+    url := srv.URL + "/contest/1336"
+    fetchText(t, f, url)
+
+    // --refresh downloads even fresh pages, without revalidating, and stores the result
+    atomic.StoreInt32(&site.version, 1)
+    cache.Refresh = true
+    if got := fetchText(t, f, url); got != `"v1"` {
+        t.Errorf("refreshed fetch %q", got)
+    }
+    if n := atomic.LoadInt32(&site.revalidated); n != 0 {
+        t.Errorf("refresh revalidated %d times", n)
+    }
+    cache.Refresh = false
+    if got := fetchText(t, f, url); got != `"v1"` {
+        t.Errorf("fetch after refresh %q", got)
+    }
+    if n := atomic.LoadInt32(&site.requests); n != 2 {
+        t.Errorf("%d requests, want 2", n)
+    }
+}
+
+func TestCacheListClear(t *testing.T) {
+    cache := &Cache{Dir: t.TempDir(), TTL: time.Hour}
+    now := time.Now()
+    cache.Put(CacheEntry{Url: "https://codeforces.com/contest/1", Fetched: now.Add(-time.Hour)}, []byte("a"))
+    cache.Put(CacheEntry{Url: "https://codeforces.com/contest/2", Fetched: now}, []byte("bb"))
+
+    entries, err := cache.List()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(entries) != 2 || entries[0].Url != "https://codeforces.com/contest/2" || entries[0].Size != 2 {
+        t.Errorf("entries %+v, want most recent first", entries)
+    }
+    if err := cache.Clear(); err != nil {
+        t.Fatal(err)
+    }
+    if entries, _ := cache.List(); len(entries) != 0 {
+        t.Errorf("%d entries left after clear", len(entries))
+    }
+    if err := (&Cache{Dir: t.TempDir() + "/missing"}).Clear(); err != nil {
+        t.Errorf("clearing a missing cache: %v", err)
+    }
+}
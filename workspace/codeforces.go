@@ -0,0 +1,134 @@
+package workspace
+
+import (
+    "fmt"
+    "net/url"
+    "regexp"
+    "strings"
+    "golang.org/x/net/html"
+)
+
+// Codeforces judge, including gyms, groups and the problemset
+// BaseUrl may point at a mirror.
+type Codeforces struct {
+    BaseUrl string
+}
+
+var (
+    sourceIdPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+    // paths of contest and problem urls, the problem is optional
+    contestPath    = regexp.MustCompile(`^/(contest|gym)/(\d+)(?:/problem/(\w+))?/?$`)
+    groupPath      = regexp.MustCompile(`^/group/([\w-]+)/contest/(\d+)(?:/problem/(\w+))?/?$`)
+    problemsetPath = regexp.MustCompile(`^/problemset/problem/(\d+)(?:/(\w+))?/?$`)
+)
+
+func (Codeforces) Name() string {
+    return CodeforcesJudge
+}
+
+// parses a contest given as
+//   a contest id            1336
+//   a typed id              contest:1336, gym:102951, problemset:1336,
+//                           group:MWSDmqGsZm/219158
+//   a contest or problem url https://codeforces.com/gym/102951/problem/A
+func (cf Codeforces) ParseSource(s string) (Source, string, error) {
+    if strings.Contains(s, "://") {
+        return cf.parseSourceUrl(s)
+    }
+    kind, id, typed := strings.Cut(s, ":")
+    if !typed {
+        kind, id = string(ContestSource), s
+    }
+    src := Source{Judge: CodeforcesJudge, Kind: SourceKind(kind), Contest: id}
+    switch src.Kind {
+    case ContestSource, GymSource, ProblemsetSource:
+    case GroupSource:
+        group, contest, ok := strings.Cut(id, "/")
+        if !ok || !sourceIdPattern.MatchString(group) {
+            return Source{}, "", fmt.Errorf("invalid group contest %q, expected group:{groupId}/{contestId}", s)
+        }
+        src.Group, src.Contest = group, contest
+    default:
+        return Source{}, "", fmt.Errorf("unknown contest kind %q in %q, expected contest, gym, group or problemset", kind, s)
+    }
+    if !sourceIdPattern.MatchString(src.Contest) {
+        return Source{}, "", fmt.Errorf("invalid contest id %q", s)
+    }
+    return src, "", nil
+}
+
+func (Codeforces) parseSourceUrl(s string) (Source, string, error) {
+    u, err := url.Parse(s)
+    if err != nil {
+        return Source{}, "", err
+    }
+    if m := contestPath.FindStringSubmatch(u.Path); m != nil {
+        return Source{Judge: CodeforcesJudge, Kind: SourceKind(m[1]), Contest: m[2]}, m[3], nil
+    }
+    if m := groupPath.FindStringSubmatch(u.Path); m != nil {
+        return Source{Judge: CodeforcesJudge, Kind: GroupSource, Group: m[1], Contest: m[2]}, m[3], nil
+    }
+    if m := problemsetPath.FindStringSubmatch(u.Path); m != nil {
+        return Source{Judge: CodeforcesJudge, Kind: ProblemsetSource, Contest: m[1]}, m[2], nil
+    }
+    return Source{}, "", fmt.Errorf("unrecognized codeforces url %q", s)
+}
+
+// problemset problems are listed on their contest page
+func (cf Codeforces) ContestUrl(s Source) string {
+    switch s.kind() {
+    case GymSource:
+        return fmt.Sprintf("%s/gym/%s", cf.BaseUrl, s.Contest)
+    case GroupSource:
+        return fmt.Sprintf("%s/group/%s/contest/%s", cf.BaseUrl, s.Group, s.Contest)
+    }
+    return fmt.Sprintf("%s/contest/%s", cf.BaseUrl, s.Contest)
+}
+
+func (cf Codeforces) ProblemUrl(s Source, problemId string) string {
+    switch s.kind() {
+    case ProblemsetSource:
+        return fmt.Sprintf("%s/problemset/problem/%s/%s", cf.BaseUrl, s.Contest, problemId)
+    case GymSource:
+        return fmt.Sprintf("%s/gym/%s/problem/%s", cf.BaseUrl, s.Contest, problemId)
+    case GroupSource:
+        return fmt.Sprintf("%s/group/%s/contest/%s/problem/%s", cf.BaseUrl, s.Group, s.Contest, problemId)
+    }
+    return fmt.Sprintf("%s/contest/%s/problem/%s", cf.BaseUrl, s.Contest, problemId)
+}
+
+func (Codeforces) ParseContest(page *html.Node) ([]ProblemSummary, error) {
+    return parseProblemList(page)
+}
+
+// scrapes the name, sample tests, limits and statement of a problem
+func (Codeforces) ParseProblem(page *html.Node) (Problem, error) {
+    // get problem name
+    name, err := parseName(page)
+    if err != nil {
+        return Problem{}, err
+    }
+    // get problem sample tests
+    // interactive problems may come without any
+    interactive := parseInteractive(page)
+    tests, err := parseTests(page)
+    if err != nil && !interactive {
+        return Problem{}, err
+    }
+    // get problem time and memory limits
+    timeLimit, memoryLimit, err := parseLimits(page)
+    if err != nil {
+        return Problem{}, err
+    }
+    // get problem statement, optional since name, tests and limits suffice
+    // for training
+    statement, _ := parseStatement(page)
+    return Problem{
+        Name:        name,
+        Tests:       tests,
+        Interactive: interactive,
+        TimeLimit:   timeLimit,
+        MemoryLimit: memoryLimit,
+        Statement:   statement,
+    }, nil
+}
@@ -0,0 +1,138 @@
+package workspace
+
+import (
+    "fmt"
+    "net/http"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// codeforces url used unless configured otherwise
+const DefaultBaseUrl = "https://codeforces.com"
+
+// defaults for the http client used to scrape pages
+const (
+    defaultTimeout   = 30 * time.Second
+    defaultUserAgent = "forces (+https://github.com/pahyde/forces)"
+    defaultRateLimit = 2.0 // requests per second
+    defaultBurst     = 4
+    defaultRetries   = 3
+)
+
+// Types for user settings stored in ~/.config/forces/config.json
+// Every field is optional.
+type Config struct {
+    BaseUrl   string // e.g. https://codeforces.ml or https://m1.codeforces.com
+    Proxy     string // proxy url, defaults to $HTTPS_PROXY/$HTTP_PROXY
+    UserAgent string
+    Timeout   string // per request, e.g. "30s"
+    Workers   int     // problem pages scraped concurrently
+    RateLimit float64 // requests per second
+    Retries   *int    // retries of failed requests
+    CacheTTL  string  // age after which cached pages are revalidated, e.g. "24h"
+    Author    string  // {{.Author}} of solution templates, defaults to $USER
+}
+
+// path to config.json in appDir
+func ConfigPath(appDir string) string {
+    return filepath.Join(appDir, "config.json")
+}
+
+// reads config.json from appDir, returning an empty Config if it doesn't exist
+func ReadConfig(appDir string) (Config, error) {
+    var c Config
+    err := readJSON(ConfigPath(appDir), &c)
+    if os.IsNotExist(err) {
+        return Config{}, nil
+    }
+    if err != nil {
+        return Config{}, fmt.Errorf("reading %s: %w", ConfigPath(appDir), err)
+    }
+    return c, nil
+}
+
+// returns an http client honoring the timeout and proxy of c
+func (c Config) HTTPClient() (*http.Client, error) {
+    timeout := defaultTimeout
+    if c.Timeout != "" {
+        t, err := time.ParseDuration(c.Timeout)
+        if err != nil {
+            return nil, fmt.Errorf("invalid timeout %q in config: %w", c.Timeout, err)
+        }
+        timeout = t
+    }
+
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    if c.Proxy != "" {
+        proxy, err := url.Parse(c.Proxy)
+        if err != nil {
+            return nil, fmt.Errorf("invalid proxy %q in config: %w", c.Proxy, err)
+        }
+        transport.Proxy = http.ProxyURL(proxy)
+    }
+    return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// returns the page cache in the default cache dir with the ttl of c
+func (c Config) Cache() (*Cache, error) {
+    dir, err := DefaultCacheDir()
+    if err != nil {
+        return nil, err
+    }
+    ttl := DefaultCacheTTL
+    if c.CacheTTL != "" {
+        t, err := time.ParseDuration(c.CacheTTL)
+        if err != nil {
+            return nil, fmt.Errorf("invalid cache ttl %q in config: %w", c.CacheTTL, err)
+        }
+        ttl = t
+    }
+    return &Cache{Dir: dir, TTL: ttl}, nil
+}
+
+// returns a rate limited fetcher retrying failed requests using the client,
+// user agent and proxy of c and caching pages in cache (if not nil)
+func (c Config) Fetcher(cache *Cache) (Fetcher, error) {
+    client, err := c.HTTPClient()
+    if err != nil {
+        return nil, err
+    }
+    userAgent := c.UserAgent
+    if userAgent == "" {
+        userAgent = defaultUserAgent
+    }
+    rate := c.RateLimit
+    if rate <= 0 {
+        rate = defaultRateLimit
+    }
+    retries := defaultRetries
+    if c.Retries != nil {
+        retries = *c.Retries
+    }
+    return HTTPFetcher{
+        Client:    client,
+        UserAgent: userAgent,
+        Limiter:   NewRateLimiter(time.Duration(float64(time.Second) / rate), defaultBurst),
+        Retries:   retries,
+        Cache:     cache,
+    }, nil
+}
+
+// author written into solution headers, the login name if not configured
+func (c Config) author() string {
+    if c.Author == "" {
+        return os.Getenv("USER")
+    }
+    return c.Author
+}
+
+// base url of c without a trailing slash
+func (c Config) baseUrl() string {
+    if c.BaseUrl == "" {
+        return DefaultBaseUrl
+    }
+    return strings.TrimRight(c.BaseUrl, "/")
+}
@@ -0,0 +1,119 @@
+package workspace
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// Types for organizing parsed data from codeforces
+// Used to write test cases to disk and generate solution files.
+type Contest struct {
+    Id       string
+    Source   Source // zero for contests not scraped from codeforces
+    Problems []Problem
+}
+
+// source of the contest, a plain contest named Id if unknown
+func (c Contest) source() Source {
+    if c.Source.Contest == "" {
+        return Source{Kind: ContestSource, Contest: c.Id}
+    }
+    return c.Source
+}
+
+// name of the directory the contest is trained in
+func (c Contest) dir() string {
+    return c.source().Dir()
+}
+
+type Problem struct {
+    Id          string
+    Name        string
+    Tests       []Test
+    Interactive bool
+    TimeLimit   time.Duration
+    MemoryLimit int64 // bytes
+    Url         string // problem page, derived from the base url, contest and problem id if empty
+    Statement   Statement
+}
+
+// sections of a problem statement converted to markdown
+// empty for problems that weren't scraped from a problem page
+type Statement struct {
+    Legend      string
+    Constraints string
+    Input       string
+    Output      string
+    Interaction string
+    Note        string
+    Tags        []string
+}
+
+// entry of a contest's problem list
+type ProblemSummary struct {
+    Id     string
+    Name   string
+    Solved int    // participants who solved the problem, -1 if not shown
+    Url    string // problem page, if it can't be derived from the ids
+}
+
+type Test struct {
+    Input  string
+    Output string
+}
+
+// reads sample tests in0.txt, out0.txt, in1.txt, out1.txt... from dir
+// stops at the first missing input file
+func ReadTests(dir string) ([]Test, error) {
+    tests := make([]Test, 0)
+    for i := 0; ; i++ {
+        in, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("in%d.txt", i)))
+        if os.IsNotExist(err) {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+        out, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("out%d.txt", i)))
+        if err != nil {
+            return nil, err
+        }
+        tests = append(tests, Test{string(in), string(out)})
+    }
+    return tests, nil
+}
+
+// writes tests to dir as in0.txt, out0.txt, in1.txt, out1.txt...
+func WriteTests(dir string, tests []Test) error {
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return err
+    }
+    for i, test := range tests {
+        if err := writeTest(dir, i, test); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// writes test as the next free inN.txt/outN.txt pair in dir
+// returns the index N of the new pair
+func AppendTest(dir string, test Test) (int, error) {
+    tests, err := ReadTests(dir)
+    if err != nil {
+        return 0, err
+    }
+    i := len(tests)
+    return i, writeTest(dir, i, test)
+}
+
+func writeTest(dir string, i int, test Test) error {
+    inputPath  := filepath.Join(dir, fmt.Sprintf("in%d.txt", i))
+    outputPath := filepath.Join(dir, fmt.Sprintf("out%d.txt", i))
+    if err := os.WriteFile(inputPath,  []byte(test.Input),  0644); err != nil {
+        return err
+    }
+    return os.WriteFile(outputPath, []byte(test.Output), 0644)
+}
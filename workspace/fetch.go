@@ -0,0 +1,195 @@
+package workspace
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "time"
+    "golang.org/x/net/html"
+)
+
+// Fetcher returns the html parse tree of the page at url
+// Workspaces scrape through a Fetcher so pages can come from anywhere,
+// e.g. saved fixtures in tests.
+type Fetcher interface {
+    Fetch(url string) (*html.Node, error)
+}
+
+// ProgressFetcher is implemented by fetchers that can report download
+// progress: read bytes of total (-1 while unknown) so far.
+type ProgressFetcher interface {
+    Fetcher
+    FetchProgress(url string, progress func(read, total int64)) (*html.Node, error)
+}
+
+// FetcherFunc adapts an ordinary function to the Fetcher interface
+type FetcherFunc func(url string) (*html.Node, error)
+
+func (f FetcherFunc) Fetch(url string) (*html.Node, error) {
+    return f(url)
+}
+
+// delay before the first retry of a failed request, doubled for every retry after
+const retryBackoff = 500 * time.Millisecond
+
+// HTTPFetcher fetches pages over http with Client (http.DefaultClient if nil)
+// sending UserAgent, if set, with every request. Requests wait for Limiter,
+// if set, and transient failures (network errors, 429 and 5xx responses)
+// are retried up to Retries times with exponential backoff.
+// Pages are served from and stored in Cache, if set. A stale cached page
+// is served when it can't be revalidated, so cached contests work offline.
+type HTTPFetcher struct {
+    Client    *http.Client
+    UserAgent string
+    Limiter   *RateLimiter
+    Retries   int
+    Cache     *Cache
+}
+
+// returns root node of html parse tree for the given url
+func (f HTTPFetcher) Fetch(url string) (*html.Node, error) {
+    return f.FetchProgress(url, nil)
+}
+
+// like Fetch, calling progress (if not nil) as the response body is read
+func (f HTTPFetcher) FetchProgress(url string, progress func(read, total int64)) (*html.Node, error) {
+    body, err := f.body(url, progress)
+    if err != nil {
+        return nil, err
+    }
+    return html.Parse(bytes.NewReader(body))
+}
+
+// returns the body of the page at url from the cache or the network
+func (f HTTPFetcher) body(url string, progress func(read, total int64)) ([]byte, error) {
+    var cached *CacheEntry
+    var cachedBody []byte
+    if f.Cache != nil && !f.Cache.Refresh {
+        if e, body, ok := f.Cache.Get(url); ok {
+            if f.Cache.Fresh(e) {
+                if progress != nil {
+                    progress(e.Size, e.Size)
+                }
+                return body, nil
+            }
+            cached, cachedBody = &e, body
+        }
+    }
+
+    backoff := retryBackoff
+    for attempt := 0; ; attempt++ {
+        entry, body, retryAfter, err := f.get(url, cached, cachedBody, progress)
+        if err == nil {
+            if f.Cache != nil {
+                if err := f.Cache.Put(entry, body); err != nil {
+                    return nil, err
+                }
+            }
+            return body, nil
+        }
+        if retryAfter < 0 || attempt >= f.Retries {
+            if cached != nil && retryAfter >= 0 {
+                // offline or the site is down, settle for the stale copy
+                if progress != nil {
+                    progress(cached.Size, cached.Size)
+                }
+                return cachedBody, nil
+            }
+            return nil, err
+        }
+        if retryAfter < backoff {
+            retryAfter = backoff
+        }
+        time.Sleep(retryAfter)
+        backoff *= 2
+    }
+}
+
+// single attempt at downloading url, revalidating the cached entry and
+// body if given. On failure retryAfter is the minimum delay before
+// retrying, or negative if the failure isn't transient.
+func (f HTTPFetcher) get(url string, cached *CacheEntry, cachedBody []byte, progress func(read, total int64)) (entry CacheEntry, body []byte, retryAfter time.Duration, err error) {
+    client := f.Client
+    if client == nil {
+        client = http.DefaultClient
+    }
+    req, err := http.NewRequest(http.MethodGet, url, nil)
+    if err != nil {
+        return CacheEntry{}, nil, -1, err
+    }
+    if f.UserAgent != "" {
+        req.Header.Set("User-Agent", f.UserAgent)
+    }
+    if cached != nil {
+        if cached.ETag != "" {
+            req.Header.Set("If-None-Match", cached.ETag)
+        }
+        if cached.LastModified != "" {
+            req.Header.Set("If-Modified-Since", cached.LastModified)
+        }
+    }
+    if f.Limiter != nil {
+        f.Limiter.Wait()
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return CacheEntry{}, nil, 0, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode == http.StatusNotModified && cached != nil {
+        entry = *cached
+        entry.Fetched = time.Now()
+        if progress != nil {
+            progress(entry.Size, entry.Size)
+        }
+        return entry, cachedBody, 0, nil
+    }
+    if resp.StatusCode != http.StatusOK {
+        err := fmt.Errorf("GET %s: %s", url, resp.Status)
+        if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+            // Retry-After in seconds, if given
+            seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+            return CacheEntry{}, nil, time.Duration(seconds) * time.Second, err
+        }
+        return CacheEntry{}, nil, -1, err
+    }
+
+    var r io.Reader = resp.Body
+    if progress != nil {
+        progress(0, resp.ContentLength)
+        r = &progressReader{r: resp.Body, total: resp.ContentLength, progress: progress}
+    }
+    body, err = io.ReadAll(r)
+    if err != nil {
+        return CacheEntry{}, nil, 0, err
+    }
+    if progress != nil {
+        // the size is known once the whole body is read
+        progress(int64(len(body)), int64(len(body)))
+    }
+    entry = CacheEntry{
+        Url:          url,
+        ETag:         resp.Header.Get("ETag"),
+        LastModified: resp.Header.Get("Last-Modified"),
+        Fetched:      time.Now(),
+    }
+    return entry, body, 0, nil
+}
+
+// reports the number of bytes read through it
+type progressReader struct {
+    r        io.Reader
+    read     int64
+    total    int64
+    progress func(read, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+    n, err := p.r.Read(b)
+    p.read += int64(n)
+    p.progress(p.read, p.total)
+    return n, err
+}
@@ -0,0 +1,153 @@
+package workspace
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// stub site answering requests with the statuses in turn, then 200 OK,
+// recording when each request arrived
+type flakySite struct {
+    mu         sync.Mutex
+    statuses   []int
+    retryAfter string
+    arrivals   []time.Time
+}
+
+func (s *flakySite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    i := len(s.arrivals)
+    s.arrivals = append(s.arrivals, time.Now())
+    if i < len(s.statuses) {
+        if s.retryAfter != "" {
+            w.Header().Set("Retry-After", s.retryAfter)
+        }
+        w.WriteHeader(s.statuses[i])
+        return
+    }
+    fmt.Fprint(w, "<html><body><p>ok</p></body></html>")
+}
+
+func (s *flakySite) requests() []time.Time {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]time.Time(nil), s.arrivals...)
+}
+
+func TestFetchRetryBackoff(t *testing.T) {
+    site := &flakySite{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+    srv := httptest.NewServer(site)
+    defer srv.Close()
+
+    f := HTTPFetcher{Retries: 2}
+    if got := fetchText(t, f, srv.URL); got != "ok" {
+        t.Errorf("fetch %q", got)
+    }
+    arrivals := site.requests()
+    if len(arrivals) != 3 {
+        t.Fatalf("%d requests, want 3", len(arrivals))
+    }
+    // the delay doubles after every failure
+    for i, want := range []time.Duration{retryBackoff, 2 * retryBackoff} {
+        if got := arrivals[i+1].Sub(arrivals[i]); got < want {
+            t.Errorf("retry %d after %v, want at least %v", i+1, got, want)
+        }
+    }
+}
+
+func TestFetchRetryAfter(t *testing.T) {
+    site := &flakySite{statuses: []int{http.StatusTooManyRequests}, retryAfter: "1"}
+    srv := httptest.NewServer(site)
+    defer srv.Close()
+
+    f := HTTPFetcher{Retries: 1}
+    fetchText(t, f, srv.URL)
+    arrivals := site.requests()
+    if len(arrivals) != 2 {
+        t.Fatalf("%d requests, want 2", len(arrivals))
+    }
+    if got := arrivals[1].Sub(arrivals[0]); got < time.Second {
+        t.Errorf("retried after %v ignoring Retry-After: 1", got)
+    }
+}
+
+func TestFetchGiveUp(t *testing.T) {
+    site := &flakySite{statuses: []int{500, 502, 503, 504}}
+    srv := httptest.NewServer(site)
+    defer srv.Close()
+
+    f := HTTPFetcher{Retries: 1}
+    _, err := f.Fetch(srv.URL)
+    if err == nil || !strings.Contains(err.Error(), "502") {
+        t.Errorf("error %v, want the last failure", err)
+    }
+    if n := len(site.requests()); n != 2 {
+        t.Errorf("%d requests, want 2 (one retry)", n)
+    }
+}
+
+func TestFetchNoRetry(t *testing.T) {
+    site := &flakySite{statuses: []int{http.StatusNotFound}}
+    srv := httptest.NewServer(site)
+    defer srv.Close()
+
+    f := HTTPFetcher{Retries: 3}
+    if _, err := f.Fetch(srv.URL); err == nil {
+        t.Error("expected an error for 404")
+    }
+    if n := len(site.requests()); n != 1 {
+        t.Errorf("%d requests, a 404 isn't transient", n)
+    }
+}
+
+func TestRateLimiter(t *testing.T) {
+    const interval = 50 * time.Millisecond
+    l := NewRateLimiter(interval, 2)
+    start := time.Now()
+    times := make([]time.Duration, 0)
+    for i := 0; i < 5; i++ {
+        l.Wait()
+        times = append(times, time.Since(start))
+    }
+    // the burst goes through at once, later requests are spaced by interval
+    if times[1] > interval/2 {
+        t.Errorf("burst of 2 took %v", times[1])
+    }
+    for i := 2; i < len(times); i++ {
+        if gap := times[i] - times[i-1]; gap < interval-5*time.Millisecond {
+            t.Errorf("request %d came %v after the previous one, want %v", i, gap, interval)
+        }
+    }
+}
+
+func TestFetchRateLimited(t *testing.T) {
+    const interval = 50 * time.Millisecond
+    site := &flakySite{}
+    srv := httptest.NewServer(site)
+    defer srv.Close()
+
+    f := HTTPFetcher{Limiter: NewRateLimiter(interval, 1)}
+    var wg sync.WaitGroup
+    for i := 0; i < 4; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            f.Fetch(srv.URL)
+        }()
+    }
+    wg.Wait()
+
+    arrivals := site.requests()
+    if len(arrivals) != 4 {
+        t.Fatalf("%d requests, want 4", len(arrivals))
+    }
+    if span := arrivals[3].Sub(arrivals[0]); span < 3*interval-10*time.Millisecond {
+        t.Errorf("4 concurrent requests arrived within %v, want them spaced by %v", span, interval)
+    }
+}
@@ -0,0 +1,136 @@
+package workspace
+
+import (
+    "flag"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+    "golang.org/x/net/html"
+)
+
+// go test ./workspace -run TestFetchFixtures -fetch replaces every
+// testdata page with a trimmed copy of the live page it stands for, to be
+// followed by -update once the goldens are checked
+var fetchFixtures = flag.Bool("fetch", false, "re-download testdata pages from codeforces and atcoder")
+
+// url of the live page a testdata/{dir}/{name}.html fixture was saved from
+func fixtureUrl(dir, name string) (string, bool) {
+    cf := Codeforces{BaseUrl: DefaultBaseUrl}
+    at := AtCoder{BaseUrl: DefaultAtCoderUrl}
+    switch dir {
+    case "contest":
+        return cf.ContestUrl(Source{Kind: ContestSource, Contest: name}), true
+    case "problem", "statement":
+        // 1336A, 1718A1
+        i := strings.IndexFunc(name, func(r rune) bool { return r < '0' || r > '9' })
+        if i <= 0 {
+            return "", false
+        }
+        return cf.ProblemUrl(Source{Kind: ContestSource, Contest: name[:i]}, name[i:]), true
+    case filepath.Join("atcoder", "tasks"):
+        return at.ContestUrl(Source{Kind: ContestSource, Contest: name}), true
+    case filepath.Join("atcoder", "problem"):
+        // abc300_a
+        contest, _, ok := strings.Cut(name, "_")
+        if !ok {
+            return "", false
+        }
+        return at.BaseUrl + "/contests/" + contest + "/tasks/" + name, true
+    }
+    return "", false
+}
+
+// removes the parts of a saved page no parser looks at: scripts, styles,
+// embedded frames and comments
+func trimPage(n *html.Node) {
+    for c := n.FirstChild; c != nil; {
+        next := c.NextSibling
+        switch {
+        case c.Type == html.CommentNode,
+            c.Type == html.ElementNode && (c.Data == "script" || c.Data == "style" || c.Data == "link" ||
+                c.Data == "noscript" || c.Data == "iframe" || c.Data == "svg"):
+            n.RemoveChild(c)
+        default:
+            trimPage(c)
+        }
+        c = next
+    }
+}
+
+func TestFetchFixtures(t *testing.T) {
+    if !*fetchFixtures {
+        t.Skip("run with -fetch to re-download the testdata pages")
+    }
+    f := HTTPFetcher{UserAgent: defaultUserAgent, Limiter: NewRateLimiter(2*time.Second, 1), Retries: 2}
+    dirs := []string{"contest", "problem", "statement", filepath.Join("atcoder", "tasks"), filepath.Join("atcoder", "problem")}
+    for _, dir := range dirs {
+        pages, err := filepath.Glob(filepath.Join("testdata", dir, "*.html"))
+        if err != nil {
+            t.Fatal(err)
+        }
+        for _, page := range pages {
+            name := strings.TrimSuffix(filepath.Base(page), ".html")
+            url, ok := fixtureUrl(dir, name)
+            if !ok {
+                t.Errorf("%s: no live page known", page)
+                continue
+            }
+            doc, err := f.Fetch(url)
+            if err != nil {
+                t.Errorf("%s: %v", page, err)
+                continue
+            }
+            trimPage(doc)
+            out, err := os.Create(page)
+            if err != nil {
+                t.Fatal(err)
+            }
+            err = html.Render(out, doc)
+            out.Close()
+            if err != nil {
+                t.Fatal(err)
+            }
+            t.Logf("%s <- %s", page, url)
+        }
+    }
+}
+
+func TestFixtureUrl(t *testing.T) {
+    cases := []struct {
+        dir, name, url string
+    }{
+        {"contest", "1336", "https://codeforces.com/contest/1336"},
+        {"problem", "1718A1", "https://codeforces.com/contest/1718/problem/A1"},
+        {"statement", "1207E", "https://codeforces.com/contest/1207/problem/E"},
+        {filepath.Join("atcoder", "tasks"), "abc300", "https://atcoder.jp/contests/abc300/tasks"},
+        {filepath.Join("atcoder", "problem"), "abc300_a", "https://atcoder.jp/contests/abc300/tasks/abc300_a"},
+    }
+    for _, c := range cases {
+        if url, ok := fixtureUrl(c.dir, c.name); !ok || url != c.url {
+            t.Errorf("fixtureUrl(%q, %q) = %q, %v, want %q", c.dir, c.name, url, ok, c.url)
+        }
+    }
+    for _, name := range []string{"A", ""} {
+        if url, ok := fixtureUrl("problem", name); ok {
+            t.Errorf("fixtureUrl(problem, %q) = %q", name, url)
+        }
+    }
+}
+
+func TestTrimPage(t *testing.T) {
+    page := `<html><head><script>x()</script><link rel="stylesheet"><title>t</title></head>` +
+        `<body><!-- ad --><div class="problem-statement"><style>p{}</style><p>kept</p></div></body></html>`
+    doc, err := html.Parse(strings.NewReader(page))
+    if err != nil {
+        t.Fatal(err)
+    }
+    trimPage(doc)
+    var b strings.Builder
+    html.Render(&b, doc)
+    want := `<html><head><title>t</title></head><body><div class="problem-statement"><p>kept</p></div></body></html>`
+    if b.String() != want {
+        t.Errorf("got %s, want %s", b.String(), want)
+    }
+}
@@ -0,0 +1,76 @@
+package workspace
+
+import (
+    "fmt"
+    "net/url"
+    "strings"
+    "golang.org/x/net/html"
+)
+
+// names of the supported judges
+const (
+    CodeforcesJudge = "codeforces"
+    AtCoderJudge    = "atcoder"
+)
+
+// Judge knows the urls and page layouts of an online judge
+// Pages are fetched by the workspace, judges only parse them.
+// Submitting isn't part of it: both judges need a logged in browser
+// session for that, which forces doesn't manage.
+type Judge interface {
+    Name() string
+    // parses a contest given as an id, typed id or url of this judge
+    // problemId is set when a problem url is given
+    ParseSource(s string) (src Source, problemId string, err error)
+    // url of the page listing the problems of src
+    ContestUrl(src Source) string
+    // url of problem problemId of src
+    ProblemUrl(src Source, problemId string) string
+    // parses the problem list of a contest page
+    ParseContest(page *html.Node) ([]ProblemSummary, error)
+    // parses a problem page, leaving Id and Url to the caller
+    ParseProblem(page *html.Node) (Problem, error)
+}
+
+// returns the judge called name, codeforces if empty
+func (w *Workspace) Judge(name string) (Judge, error) {
+    switch strings.ToLower(name) {
+    case "", CodeforcesJudge:
+        return Codeforces{BaseUrl: w.baseUrl()}, nil
+    case AtCoderJudge:
+        return AtCoder{BaseUrl: DefaultAtCoderUrl}, nil
+    }
+    return nil, fmt.Errorf("unknown judge %q, expected %s or %s", name, CodeforcesJudge, AtCoderJudge)
+}
+
+// parses contest s with the judge called judge, or if empty the judge
+// named by a judge:contest prefix or owning the url s, codeforces otherwise
+func (w *Workspace) ParseSource(s, judge string) (Source, string, error) {
+    if judge == "" {
+        judge = judgeOf(s)
+    }
+    if prefix, rest, ok := strings.Cut(s, ":"); ok && strings.EqualFold(prefix, judge) {
+        s = rest
+    }
+    j, err := w.Judge(judge)
+    if err != nil {
+        return Source{}, "", err
+    }
+    return j.ParseSource(s)
+}
+
+// name of the judge a contest string refers to
+func judgeOf(s string) string {
+    if strings.Contains(s, "://") {
+        if u, err := url.Parse(s); err == nil && strings.HasSuffix(u.Hostname(), "atcoder.jp") {
+            return AtCoderJudge
+        }
+        return CodeforcesJudge
+    }
+    prefix, _, _ := strings.Cut(s, ":")
+    switch strings.ToLower(prefix) {
+    case CodeforcesJudge, AtCoderJudge:
+        return strings.ToLower(prefix)
+    }
+    return CodeforcesJudge
+}
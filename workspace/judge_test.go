@@ -0,0 +1,120 @@
+package workspace
+
+import (
+    "strings"
+    "testing"
+    "golang.org/x/net/html"
+)
+
+func TestParseSource(t *testing.T) {
+    w := &Workspace{}
+    cases := []struct {
+        in         string
+        src        Source
+        problemId  string
+        problemUrl string
+    }{
+        {"1336", Source{Judge: CodeforcesJudge, Kind: ContestSource, Contest: "1336"}, "", "/contest/1336/problem/A"},
+        {"contest:1336", Source{Judge: CodeforcesJudge, Kind: ContestSource, Contest: "1336"}, "", "/contest/1336/problem/A"},
+        {"gym:102951", Source{Judge: CodeforcesJudge, Kind: GymSource, Contest: "102951"}, "", "/gym/102951/problem/A"},
+        {"problemset:1336", Source{Judge: CodeforcesJudge, Kind: ProblemsetSource, Contest: "1336"}, "", "/problemset/problem/1336/A"},
+        {"group:MWSDmqGsZm/219158", Source{Judge: CodeforcesJudge, Kind: GroupSource, Group: "MWSDmqGsZm", Contest: "219158"}, "", "/group/MWSDmqGsZm/contest/219158/problem/A"},
+        {"https://codeforces.com/contest/1336", Source{Judge: CodeforcesJudge, Kind: ContestSource, Contest: "1336"}, "", "/contest/1336/problem/A"},
+        {"https://codeforces.com/contest/1336/problem/E1", Source{Judge: CodeforcesJudge, Kind: ContestSource, Contest: "1336"}, "E1", "/contest/1336/problem/A"},
+        {"https://codeforces.com/gym/102951/problem/B", Source{Judge: CodeforcesJudge, Kind: GymSource, Contest: "102951"}, "B", "/gym/102951/problem/A"},
+        {"https://codeforces.com/group/MWSDmqGsZm/contest/219158/problem/C", Source{Judge: CodeforcesJudge, Kind: GroupSource, Group: "MWSDmqGsZm", Contest: "219158"}, "C", "/group/MWSDmqGsZm/contest/219158/problem/A"},
+        {"https://codeforces.com/problemset/problem/1336/A", Source{Judge: CodeforcesJudge, Kind: ProblemsetSource, Contest: "1336"}, "A", "/problemset/problem/1336/A"},
+        {"atcoder:abc300", Source{Judge: AtCoderJudge, Kind: ContestSource, Contest: "abc300"}, "", "https://atcoder.jp/contests/abc300/tasks/abc300_a"},
+        {"https://atcoder.jp/contests/abc300/tasks", Source{Judge: AtCoderJudge, Kind: ContestSource, Contest: "abc300"}, "", "https://atcoder.jp/contests/abc300/tasks/abc300_a"},
+        {"https://atcoder.jp/contests/arc150/tasks/arc150_b", Source{Judge: AtCoderJudge, Kind: ContestSource, Contest: "arc150"}, "B", "https://atcoder.jp/contests/arc150/tasks/arc150_a"},
+    }
+    for _, c := range cases {
+        src, problemId, err := w.ParseSource(c.in, "")
+        if err != nil {
+            t.Errorf("ParseSource(%q): %v", c.in, err)
+            continue
+        }
+        if src != c.src || problemId != c.problemId {
+            t.Errorf("ParseSource(%q) = %+v, %q, want %+v, %q", c.in, src, problemId, c.src, c.problemId)
+        }
+        judge, err := w.Judge(src.Judge)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := strings.TrimPrefix(judge.ProblemUrl(src, "A"), DefaultBaseUrl); got != c.problemUrl {
+            t.Errorf("ProblemUrl of %q = %q, want %q", c.in, got, c.problemUrl)
+        }
+    }
+
+    src, _, err := w.ParseSource("abc300", AtCoderJudge)
+    if err != nil || src.Judge != AtCoderJudge {
+        t.Errorf(`ParseSource("abc300", %q) = %+v, %v`, AtCoderJudge, src, err)
+    }
+
+    for _, in := range []string{"", "foo:1", "group:219158", "1336/A", "https://codeforces.com/blog/entry/1", "atcoder:abc/300", "https://atcoder.jp/posts/1"} {
+        if src, _, err := w.ParseSource(in, ""); err == nil {
+            t.Errorf("ParseSource(%q) = %+v, want error", in, src)
+        }
+    }
+}
+
+// parses testdata/atcoder/tasks/*.html task lists and
+// testdata/atcoder/problem/*.html task pages against their .golden files
+func TestAtCoderGolden(t *testing.T) {
+    a := AtCoder{BaseUrl: DefaultAtCoderUrl}
+    forEachFixture(t, "atcoder/tasks", func(t *testing.T, doc *html.Node) any {
+        problems, err := a.ParseContest(doc)
+        if err != nil {
+            t.Fatalf("ParseContest: %v", err)
+        }
+        return problems
+    })
+    forEachFixture(t, "atcoder/problem", func(t *testing.T, doc *html.Node) any {
+        problem, err := a.ParseProblem(doc)
+        if err != nil {
+            t.Fatalf("ParseProblem: %v", err)
+        }
+        return problem
+    })
+}
+
+func TestAtCoderInteractive(t *testing.T) {
+    page := func(sections string) string {
+        return `<span class="h2">A - Task</span><p>Time Limit: 2 sec / Memory Limit: 1024 MB</p>` +
+            `<div id="task-statement"><span class="lang"><span class="lang-en">` + sections + `</span></span></div>`
+    }
+    sample := `<section><h3>Sample Input 1</h3><pre>1</pre></section><section><h3>Sample Output 1</h3><pre>2</pre></section>`
+    cases := []struct {
+        name        string
+        html        string
+        interactive bool
+        input       string
+        interaction string
+    }{
+        {"plain", page(`<section><h3>Input</h3><p>in</p></section>` + sample), false, "in", ""},
+        {"interaction heading", page(`<section><h3>Interaction</h3><p>talk</p></section>`), true, "", "talk"},
+        {"japanese interaction heading", page(`<section><h3>インタラクション</h3><p>talk</p></section>`), true, "", "talk"},
+        {"interactive input and output", page(`<section><h3>Problem Statement</h3><p>This is an interactive task.</p></section>` +
+            `<section><h3>Input and Output</h3><p>talk</p></section>`), true, "", "talk"},
+        {"japanese interactive", page(`<section><h3>問題文</h3><p>この問題はインタラクティブな問題です。</p></section>` +
+            `<section><h3>入出力</h3><p>talk</p></section>`), true, "", "talk"},
+        // a combined input/output section alone doesn't make a task interactive
+        {"combined input and output", page(`<section><h3>入出力</h3><p>in</p></section>` + sample), false, "in", ""},
+        {"sample io", page(`<section><h3>Input</h3><p>in</p></section><section><h3>入出力例</h3><pre>1</pre></section>` + sample), false, "in", ""},
+    }
+    for _, c := range cases {
+        doc, err := html.Parse(strings.NewReader(c.html))
+        if err != nil {
+            t.Fatal(err)
+        }
+        p, err := AtCoder{}.ParseProblem(doc)
+        if err != nil {
+            t.Errorf("%s: %v", c.name, err)
+            continue
+        }
+        if p.Interactive != c.interactive || p.Statement.Input != c.input || p.Statement.Interaction != c.interaction {
+            t.Errorf("%s: interactive %v, input %q, interaction %q, want %v, %q, %q",
+                c.name, p.Interactive, p.Statement.Input, p.Statement.Interaction, c.interactive, c.input, c.interaction)
+        }
+    }
+}
@@ -0,0 +1,177 @@
+package workspace
+
+import (
+    "regexp"
+    "strconv"
+    "strings"
+    "golang.org/x/net/html"
+
+    "github.com/pahyde/forces/internal/query"
+)
+
+// converts the children of html node n to markdown
+// codeforces TeX fragments are rewritten to the usual markdown math
+// delimiters: $$$x$$$ -> $x$ (inline) and $$$$$$x$$$$$$ -> $$x$$ (display)
+func markdown(n *html.Node) string {
+    var b strings.Builder
+    for c := n.FirstChild; c != nil; c = c.NextSibling {
+        writeMarkdown(&b, c)
+    }
+    return tidyMarkdown(b.String())
+}
+
+// converts n and its descendants to markdown written to b
+// block elements are surrounded by blank lines, removed again by tidyMarkdown
+func writeMarkdown(b *strings.Builder, n *html.Node) {
+    switch n.Type {
+    case html.TextNode:
+        text := texToMarkdown(collapseSpace(n.Data))
+        // whitespace at the start of a line is markup, not text
+        if b.Len() == 0 || strings.HasSuffix(b.String(), "\n") {
+            text = strings.TrimLeft(text, " ")
+        }
+        b.WriteString(text)
+        return
+    case html.ElementNode:
+    default:
+        return
+    }
+
+    inline := func(open, close string) {
+        var inner strings.Builder
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            writeMarkdown(&inner, c)
+        }
+        text := strings.TrimSpace(inner.String())
+        if text == "" {
+            return
+        }
+        b.WriteString(open + text + close)
+    }
+
+    switch n.Data {
+    case "p", "div", "center", "section", "blockquote":
+        b.WriteString("\n\n")
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            writeMarkdown(b, c)
+        }
+        b.WriteString("\n\n")
+    case "br":
+        b.WriteString("\n")
+    case "pre":
+        b.WriteString("\n\n```\n")
+        b.WriteString(strings.Trim(preText(n), "\n"))
+        b.WriteString("\n```\n\n")
+    case "ul", "ol":
+        b.WriteString("\n\n")
+        i := 1
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            if c.Type != html.ElementNode || c.Data != "li" {
+                continue
+            }
+            bullet := "- "
+            if n.Data == "ol" {
+                bullet = strconv.Itoa(i) + ". "
+            }
+            item := markdown(c)
+            // indent continuation lines under the bullet
+            item = strings.ReplaceAll(item, "\n", "\n"+strings.Repeat(" ", len(bullet)))
+            b.WriteString(bullet + item + "\n")
+            i++
+        }
+        b.WriteString("\n")
+    case "b", "strong":
+        inline("**", "**")
+    case "i", "em":
+        inline("*", "*")
+    case "tt", "code":
+        inline("`", "`")
+    case "var":
+        // atcoder marks up math as <var>
+        inline("$", "$")
+    case "span":
+        switch {
+        case query.HasClass(n, "tex-font-style-bf"):
+            inline("**", "**")
+        case query.HasClass(n, "tex-font-style-it"), query.HasClass(n, "tex-font-style-sl"):
+            inline("*", "*")
+        case query.HasClass(n, "tex-font-style-tt"):
+            inline("`", "`")
+        default:
+            inline("", "")
+        }
+    case "a":
+        inline("[", "]("+query.Attr(n, "href")+")")
+    case "img":
+        b.WriteString("![](" + query.Attr(n, "src") + ")")
+    case "script", "style":
+    default:
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            writeMarkdown(b, c)
+        }
+    }
+}
+
+// display math first so its delimiters aren't taken for two inline ones
+func texToMarkdown(s string) string {
+    s = strings.ReplaceAll(s, "$$$$$$", "$$")
+    return strings.ReplaceAll(s, "$$$", "$")
+}
+
+var spaceRun = regexp.MustCompile(`\s+`)
+
+// collapses whitespace runs to a single space like a browser does
+func collapseSpace(s string) string {
+    return spaceRun.ReplaceAllString(s, " ")
+}
+
+var blankRun = regexp.MustCompile(`\n{3,}`)
+
+// trims trailing spaces of lines and collapses runs of blank lines,
+// keeping indentation (of list items) and code blocks as they are
+func tidyMarkdown(s string) string {
+    lines := strings.Split(s, "\n")
+    fenced := false
+    for i, line := range lines {
+        if strings.HasPrefix(line, "```") {
+            fenced = !fenced
+            continue
+        }
+        if !fenced {
+            lines[i] = strings.TrimRight(line, " ")
+        }
+    }
+    s = blankRun.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+    return strings.Trim(s, "\n")
+}
+
+// text of a <pre> element with <br> as line breaks
+// samples in the newer format hold one <div class="test-example-line">
+// per line, separated by whitespace that isn't part of the text
+func preText(n *html.Node) string {
+    lines := make([]string, 0)
+    for c := n.FirstChild; c != nil; c = c.NextSibling {
+        if c.Type == html.ElementNode && query.HasClass(c, "test-example-line") {
+            lines = append(lines, query.Text(c))
+        }
+    }
+    if len(lines) > 0 {
+        return strings.Join(lines, "\n") + "\n"
+    }
+
+    var b strings.Builder
+    var walk func(n *html.Node)
+    walk = func(n *html.Node) {
+        switch {
+        case n.Type == html.TextNode:
+            b.WriteString(n.Data)
+        case n.Type == html.ElementNode && n.Data == "br":
+            b.WriteString("\n")
+        }
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            walk(c)
+        }
+    }
+    walk(n)
+    return b.String()
+}
@@ -0,0 +1,74 @@
+package workspace
+
+import (
+    "strings"
+    "testing"
+    "golang.org/x/net/html"
+)
+
+// renders every testdata/statement/*.html problem page as the markdown
+// written to {problemId}.md and compares it against its .md file
+func TestStatementGolden(t *testing.T) {
+    forEachGolden(t, "statement", ".md", func(t *testing.T, doc *html.Node) []byte {
+        p, err := Codeforces{}.ParseProblem(doc)
+        if err != nil {
+            t.Fatalf("ParseProblem: %v", err)
+        }
+        return renderStatement(p)
+    })
+}
+
+func TestMarkdown(t *testing.T) {
+    cases := []struct {
+        name string
+        html string
+        want string
+    }{
+        {"inline tex", `<p>Given $$$n$$$ integers $$$a_1, \ldots, a_n$$$.</p>`, `Given $n$ integers $a_1, \ldots, a_n$.`},
+        {"display tex", `<p>$$$$$$\sum_{i=1}^n a_i$$$$$$</p>`, `$$\sum_{i=1}^n a_i$$`},
+        {"mixed tex", `<p>$$$x$$$ and $$$$$$y$$$$$$</p>`, "$x$ and $$y$$"},
+        {"tex styles", `<p><span class="tex-font-style-bf">bold</span>, <span class="tex-font-style-it">it</span>, <span class="tex-font-style-tt">mono</span></p>`, "**bold**, *it*, `mono`"},
+        {"empty emphasis", `<p>a<b> </b>b</p>`, "ab"},
+        {"paragraphs", "<p>one\n  two</p>\n\n\n<p>three</p>", "one two\n\nthree"},
+        {"br", `<p>a<br>b</p>`, "a\nb"},
+        {"unordered list", `<ul> <li> first; </li><li> second. </li></ul>`, "- first;\n- second."},
+        {"ordered list", `<ol><li>a</li><li><p>b</p><p>c</p></li></ol>`, "1. a\n2. b\n\n   c"},
+        {"link", `<p>see <a href="/blog/entry/1">the blog</a></p>`, "see [the blog](/blog/entry/1)"},
+        {"image", `<center><img class="tex-graphics" src="https://espresso.codeforces.com/x.png" /></center>`, "![](https://espresso.codeforces.com/x.png)"},
+        {"pre", "<p>input:</p><pre>1  2\n3</pre>", "input:\n\n```\n1  2\n3\n```"},
+        {"atcoder var", `<p><var>N</var> and <var>1 \leq N</var></p>`, `$N$ and $1 \leq N$`},
+        {"entities", `<p>$$$a &lt; b$$$ &amp; c</p>`, "$a < b$ & c"},
+        {"script", `<p>x</p><script>var y = 1;</script>`, "x"},
+    }
+    for _, c := range cases {
+        doc, err := html.Parse(strings.NewReader("<div>" + c.html + "</div>"))
+        if err != nil {
+            t.Fatal(err)
+        }
+        body := doc.FirstChild.LastChild
+        if got := markdown(body.FirstChild); got != c.want {
+            t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+        }
+    }
+}
+
+func TestTidyMarkdown(t *testing.T) {
+    cases := map[string]string{
+        "\n\na \n\n\n\n  b \n\n":        "a\n\n  b",
+        "x\n\n```\n  1 \n\n\n\n2\n```\n": "x\n\n```\n  1 \n\n2\n```",
+        "":                               "",
+    }
+    for in, want := range cases {
+        if got := tidyMarkdown(in); got != want {
+            t.Errorf("tidyMarkdown(%q) = %q, want %q", in, got, want)
+        }
+    }
+}
+
+func TestRenderStatement(t *testing.T) {
+    p := Problem{Id: "C", Name: "C. Third", Statement: Statement{Legend: "c", Tags: []string{"dp"}}}
+    want := "# C. Third\n\nc\n\nTags: dp\n"
+    if got := string(renderStatement(p)); got != want {
+        t.Errorf("got %q, want %q", got, want)
+    }
+}
@@ -0,0 +1,283 @@
+package workspace
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+    "golang.org/x/net/html"
+
+    "github.com/pahyde/forces/internal/query"
+)
+
+// ParseError reports markup a parser couldn't make sense of
+type ParseError struct {
+    Page string // kind of page, e.g. "contest"
+    Row  int    // 1-based row of a table, 0 if not about a row
+    Msg  string
+}
+
+func (e *ParseError) Error() string {
+    if e.Row > 0 {
+        return fmt.Sprintf("parsing %s page: row %d: %s", e.Page, e.Row, e.Msg)
+    }
+    return fmt.Sprintf("parsing %s page: %s", e.Page, e.Msg)
+}
+
+// problem ids are a letter with optional suffix: A, E1, F2, H...
+var problemIdPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// true if id can name a problem
+func ValidProblemId(id string) bool {
+    return problemIdPattern.MatchString(id)
+}
+
+// "x6137" in the solved count column
+var solvedPattern = regexp.MustCompile(`x\s*(\d+)`)
+
+// parses the problem list of a CF contest page
+// input "contest" is an html root node corresponding to a url of the form:
+// https://codeforces.com/contest/{contestId}/
+func parseProblemList(contest *html.Node) ([]ProblemSummary, error) {
+    // <table class="problems">
+    //     <tr><th>#</th><th>Name</th>...</tr>
+    //     <tr>
+    //         <td class="id"><a href="/contest/1336/problem/A">A</a></td>
+    //         <td><div><a href="/contest/1336/problem/A">Linova and Kingdom</a></div>...</td>
+    //         <td class="act">...</td>
+    //         <td><a href="/contest/1336/status/A">x6137</a></td>
+    //     </tr>
+    //     ...
+    // </table>
+    // header rows have no td.id, combined rounds and subproblems (E1, E2)
+    // are just more rows
+    table := query.Query(contest, "table.problems")
+    if table == nil {
+        return nil, &ParseError{Page: "contest", Msg: "<table class=\"problems\"> not found"}
+    }
+
+    problems := make([]ProblemSummary, 0)
+    seen := make(map[string]bool)
+    for i, tr := range query.QueryAll(table, "tr") {
+        row := i + 1
+        cells := query.QueryAll(tr, "> td")
+        if len(cells) == 0 || !query.HasClass(cells[0], "id") {
+            // header row
+            continue
+        }
+
+        id := strings.TrimSpace(query.Text(cells[0]))
+        if !problemIdPattern.MatchString(id) {
+            return nil, &ParseError{Page: "contest", Row: row, Msg: fmt.Sprintf("invalid problem id %q", id)}
+        }
+        if seen[id] {
+            return nil, &ParseError{Page: "contest", Row: row, Msg: fmt.Sprintf("duplicate problem id %q", id)}
+        }
+        seen[id] = true
+
+        p := ProblemSummary{Id: id, Solved: -1}
+        if len(cells) > 1 {
+            // name is the first link of the second cell, the rest of
+            // the cell holds io files and limits
+            if a := query.Query(cells[1], "a"); a != nil {
+                p.Name = strings.TrimSpace(collapseSpace(query.Text(a)))
+            }
+        }
+        for _, a := range query.QueryAll(tr, "a") {
+            if !strings.Contains(query.Attr(a, "href"), "/status/") {
+                continue
+            }
+            if m := solvedPattern.FindStringSubmatch(query.Text(a)); m != nil {
+                p.Solved, _ = strconv.Atoi(m[1])
+            }
+        }
+        problems = append(problems, p)
+    }
+    if len(problems) == 0 {
+        return nil, &ParseError{Page: "contest", Msg: "problem list is empty"}
+    }
+    return problems, nil
+}
+
+// parses the name of a codeforces problem from an html parse tree
+// input "problem" is an html root node corresponding to a url of the form:
+// https://codeforces.com/contest/{contestId}/problem/{problemId}
+func parseName(problem *html.Node) (string, error) {
+    // <div class="header"><div class="title">A. Linova and Kingdom</div>...</div>
+    title := query.Query(problem, ".header > .title")
+    if title == nil {
+        return "", fmt.Errorf("problem name not found")
+    }
+    return strings.TrimSpace(query.Text(title)), nil
+}
+
+// parses the statement sections and tags of a codeforces problem from an html parse tree
+// input: "problem" is an html root node corresponding to a url of the form:
+// https://codeforces.com/contest/{contestId}/problem/{problemId}
+func parseStatement(problem *html.Node) (Statement, error) {
+    // sections are the children of the statement:
+    // <div class="problem-statement">
+    //     <div class="header">...</div>
+    //     <div>{legend}</div>
+    //     <div class="input-specification"><div class="section-title">Input</div>...</div>
+    //     <div class="output-specification">...</div>
+    //     <div class="sample-tests">...</div>
+    //     <div class="note">...</div>
+    // </div>
+    statement := query.Query(problem, ".problem-statement")
+    if statement == nil {
+        return Statement{}, fmt.Errorf("<div class=\"problem-statement\"><\\div> not found")
+    }
+    // markdown of a section without its title
+    section := func(n *html.Node) string {
+        var b strings.Builder
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            if c.Type == html.ElementNode && query.HasClass(c, "section-title") {
+                continue
+            }
+            writeMarkdown(&b, c)
+        }
+        return tidyMarkdown(b.String())
+    }
+
+    var s Statement
+    for _, c := range query.QueryAll(statement, "> div") {
+        switch {
+        case query.HasClass(c, "header"), query.HasClass(c, "sample-tests"):
+        case query.HasClass(c, "input-specification"):
+            s.Input = section(c)
+        case query.HasClass(c, "output-specification"):
+            s.Output = section(c)
+        case query.HasClass(c, "interaction"):
+            s.Interaction = section(c)
+        case query.HasClass(c, "note"):
+            s.Note = section(c)
+        case query.Attr(c, "class") == "":
+            s.Legend = section(c)
+        }
+    }
+
+    // tags live in the sidebar:
+    // <span class="tag-box" title="Dynamic Programming">dp</span>
+    for _, n := range query.QueryAll(problem, ".tag-box") {
+        if tag := strings.TrimSpace(markdown(n)); tag != "" {
+            s.Tags = append(s.Tags, tag)
+        }
+    }
+    return s, nil
+}
+
+// parses the sample tests of a codeforces problem from an html parse tree
+// input: "problem" is an html root node corresponding to a url of the form:
+// https://codeforces.com/contest/{contestId}/problem/{problemId}
+func parseTests(problem *html.Node) ([]Test, error) {
+    // dfs for <div class="sample-tests">
+    // contains input and output for each sample test:
+    // <div class="sample-tests">
+    //     <div class="section-title">Examples</div>
+    //     <div class="sample-test">
+    //         <div class="input">
+    //             <div class="title">Input<div class="input-output-copier">Copy</div></div>
+    //             <pre>...</pre>
+    //         </div>
+    //         <div class="output">...</div>
+    //         <div class="input">...</div>
+    //         ...
+    //     </div>
+    // </div>
+    // <pre> holds lines separated by <br> in older problems and one
+    // <div class="test-example-line"> per line in newer ones
+    sampleTests := query.Query(problem, ".sample-tests")
+    if sampleTests == nil {
+        sampleTests = query.Query(problem, ".sample-test")
+    }
+    if sampleTests == nil {
+        return nil, fmt.Errorf("<div class=\"sample-tests\"><\\div> not found")
+    }
+    inputs  := query.QueryAll(sampleTests, ".input pre")
+    outputs := query.QueryAll(sampleTests, ".output pre")
+
+    if len(inputs) != len(outputs) {
+        return nil, fmt.Errorf("found %d sample inputs but %d outputs", len(inputs), len(outputs))
+    }
+    if len(inputs) == 0 {
+        return nil, fmt.Errorf("no sample tests found")
+    }
+    tests := make([]Test, 0, len(inputs))
+    for i := range inputs {
+        tests = append(tests, Test{Input: sampleText(inputs[i]), Output: sampleText(outputs[i])})
+    }
+    return tests, nil
+}
+
+// text of a sample <pre> with a trailing newline, as a test file expects
+func sampleText(pre *html.Node) string {
+    text := strings.ReplaceAll(preText(pre), "\r\n", "\n")
+    text  = strings.Trim(text, "\n")
+    if text == "" {
+        return ""
+    }
+    return text + "\n"
+}
+
+// reports whether a codeforces problem is interactive, i.e. its statement
+// has an "Interaction" section in place of (or besides) the output specification
+// input: "problem" is an html root node corresponding to a url of the form:
+// https://codeforces.com/contest/{contestId}/problem/{problemId}
+func parseInteractive(problem *html.Node) bool {
+    for _, title := range query.QueryAll(problem, ".problem-statement .section-title") {
+        if strings.TrimSpace(query.Text(title)) == "Interaction" {
+            return true
+        }
+    }
+    legend := query.Query(problem, ".problem-statement")
+    return legend != nil && strings.Contains(query.Text(legend), "This is an interactive problem")
+}
+
+// parses the time and memory limit of a codeforces problem from an html parse tree
+// input: "problem" is an html root node corresponding to a url of the form:
+// https://codeforces.com/contest/{contestId}/problem/{problemId}
+func parseLimits(problem *html.Node) (time.Duration, int64, error) {
+    // limits are the text following the property title:
+    // <div class="time-limit">
+    //     <div class="property-title">time limit per test</div>2 seconds
+    // </div>
+    // <div class="memory-limit">
+    //     <div class="property-title">memory limit per test</div>256 megabytes
+    // </div>
+    limitText := func(class string) (string, error) {
+        n := query.Query(problem, ".header ." + class)
+        if n == nil {
+            return "", fmt.Errorf("<div class=\"%s\"><\\div> not found", class)
+        }
+        var text string
+        for c := n.FirstChild; c != nil; c = c.NextSibling {
+            if c.Type == html.TextNode {
+                text += c.Data
+            }
+        }
+        return strings.TrimSpace(text), nil
+    }
+
+    // e.g. "2 seconds", "1 second", "2.5 seconds"
+    t, err := limitText("time-limit")
+    if err != nil {
+        return 0, 0, err
+    }
+    var seconds float64
+    if _, err := fmt.Sscanf(t, "%g", &seconds); err != nil {
+        return 0, 0, fmt.Errorf("unrecognized time limit %q", t)
+    }
+
+    // e.g. "256 megabytes"
+    m, err := limitText("memory-limit")
+    if err != nil {
+        return 0, 0, err
+    }
+    var megabytes int64
+    if _, err := fmt.Sscanf(m, "%d", &megabytes); err != nil {
+        return 0, 0, fmt.Errorf("unrecognized memory limit %q", m)
+    }
+    return time.Duration(seconds * float64(time.Second)), megabytes << 20, nil
+}
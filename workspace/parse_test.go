@@ -0,0 +1,229 @@
+package workspace
+
+import (
+    "bytes"
+    "encoding/json"
+    "flag"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+    "golang.org/x/net/html"
+)
+
+// go test ./workspace -update rewrites the golden files from the fixtures
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// what the parsers extract from a saved problem page
+type parsedProblem struct {
+    Name        string
+    TimeLimit   time.Duration
+    MemoryLimit int64
+    Tests       []Test
+}
+
+// parses every testdata/problem/*.html problem page and compares the
+// result against its .golden file
+func TestParseProblemGolden(t *testing.T) {
+    forEachFixture(t, "problem", func(t *testing.T, doc *html.Node) any {
+        var got parsedProblem
+        var err error
+        if got.Name, err = parseName(doc); err != nil {
+            t.Fatalf("parseName: %v", err)
+        }
+        if got.TimeLimit, got.MemoryLimit, err = parseLimits(doc); err != nil {
+            t.Fatalf("parseLimits: %v", err)
+        }
+        if got.Tests, err = parseTests(doc); err != nil {
+            t.Fatalf("parseTests: %v", err)
+        }
+        return got
+    })
+}
+
+// parses every testdata/contest/*.html contest page and compares the
+// problem list against its .golden file
+func TestParseProblemListGolden(t *testing.T) {
+    forEachFixture(t, "contest", func(t *testing.T, doc *html.Node) any {
+        problems, err := parseProblemList(doc)
+        if err != nil {
+            t.Fatalf("parseProblemList: %v", err)
+        }
+        return problems
+    })
+}
+
+func TestParseProblemListErrors(t *testing.T) {
+    cases := []struct {
+        page string
+        row  int
+    }{
+        {`<div>no problems here</div>`, 0},
+        {`<table class="problems"><tr><th>#</th></tr></table>`, 0},
+        {`<table class="problems"><tr><th>#</th></tr><tr><td class="id">A</td></tr><tr><td class="id">??</td></tr></table>`, 3},
+        {`<table class="problems"><tr><td class="id">A</td></tr><tr><td class="id">A</td></tr></table>`, 2},
+    }
+    for _, c := range cases {
+        doc, err := html.Parse(strings.NewReader(c.page))
+        if err != nil {
+            t.Fatal(err)
+        }
+        _, err = parseProblemList(doc)
+        perr, ok := err.(*ParseError)
+        if !ok {
+            t.Errorf("parseProblemList(%s) error = %v, want *ParseError", c.page, err)
+            continue
+        }
+        if perr.Row != c.row {
+            t.Errorf("parseProblemList(%s) error row = %d, want %d", c.page, perr.Row, c.row)
+        }
+    }
+}
+
+// runs parse on every testdata/{dir}/*.html page and compares the json
+// of the result with the page's .golden file
+func forEachFixture(t *testing.T, dir string, parse func(*testing.T, *html.Node) any) {
+    forEachGolden(t, dir, ".golden", func(t *testing.T, doc *html.Node) []byte {
+        dat, err := json.MarshalIndent(parse(t, doc), "", "    ")
+        if err != nil {
+            t.Fatal(err)
+        }
+        return dat
+    })
+}
+
+// runs render on every testdata/{dir}/*.html page and compares the output
+// with the page's golden file, the page name with extension ext
+func forEachGolden(t *testing.T, dir, ext string, render func(*testing.T, *html.Node) []byte) {
+    pages, err := filepath.Glob(filepath.Join("testdata", dir, "*.html"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(pages) == 0 {
+        t.Fatalf("no fixtures in testdata/%s", dir)
+    }
+    for _, page := range pages {
+        name := strings.TrimSuffix(filepath.Base(page), ".html")
+        t.Run(name, func(t *testing.T) {
+            got := bytes.TrimSpace(render(t, readFixture(t, page)))
+            golden := strings.TrimSuffix(page, ".html") + ext
+            if *update {
+                if err := os.WriteFile(golden, append(got, '\n'), 0644); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            want, err := os.ReadFile(golden)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if string(bytes.TrimSpace(want)) != string(got) {
+                t.Errorf("%s differs from %s:\ngot:\n%s\nwant:\n%s", page, golden, got, want)
+            }
+        })
+    }
+}
+
+// sample files written by earlier versions must match the fixtures they
+// were scraped from
+func TestParseTestsMatchesSamples(t *testing.T) {
+    cases := []struct {
+        page string
+        dir  string
+    }{
+        {"1336A.html", "../1336/tests/A"},
+        {"1336C.html", "../1336/tests/C"},
+        {"1713F.html", "../1713/tests/F"},
+        {"1718A1.html", "../1718/tests/A1"},
+    }
+    for _, c := range cases {
+        tests, err := parseTests(readFixture(t, filepath.Join("testdata", "problem", c.page)))
+        if err != nil {
+            t.Fatalf("%s: %v", c.page, err)
+        }
+        samples, err := ReadTests(c.dir)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if len(tests) != len(samples) {
+            t.Fatalf("%s: parsed %d tests, %s has %d", c.page, len(tests), c.dir, len(samples))
+        }
+        for i := range tests {
+            if trimLines(tests[i].Input) != trimLines(samples[i].Input) {
+                t.Errorf("%s test %d: input %q, want %q", c.page, i, tests[i].Input, samples[i].Input)
+            }
+            if trimLines(tests[i].Output) != trimLines(samples[i].Output) {
+                t.Errorf("%s test %d: output %q, want %q", c.page, i, tests[i].Output, samples[i].Output)
+            }
+        }
+    }
+}
+
+func TestParseTestsFormats(t *testing.T) {
+    cases := []struct {
+        name string
+        pre  string
+        want string
+    }{
+        {"br", `<pre>1 2<br />3 4<br /></pre>`, "1 2\n3 4\n"},
+        {"br without trailing", `<pre>1 2<br>3 4</pre>`, "1 2\n3 4\n"},
+        {"newlines", "<pre>\n1 2\n3 4\n</pre>", "1 2\n3 4\n"},
+        {"lines", "<pre>\n<div class=\"test-example-line test-example-line-even test-example-line-0\">1 2</div>\n" +
+            "<div class=\"test-example-line test-example-line-odd test-example-line-1\">3 4</div>\n</pre>", "1 2\n3 4\n"},
+        {"crlf", "<pre>1 2\r\n3 4\r\n</pre>", "1 2\n3 4\n"},
+    }
+    for _, c := range cases {
+        page := `<div class="sample-tests"><div class="sample-test">` +
+            `<div class="input"><div class="title">Input<div class="input-output-copier">Copy</div></div>` + c.pre + `</div>` +
+            `<div class="output"><div class="title">Output<div class="input-output-copier">Copy</div></div><pre>ok</pre></div>` +
+            `</div></div>`
+        doc, err := html.Parse(strings.NewReader(page))
+        if err != nil {
+            t.Fatal(err)
+        }
+        tests, err := parseTests(doc)
+        if err != nil {
+            t.Fatalf("%s: %v", c.name, err)
+        }
+        want := []Test{{Input: c.want, Output: "ok\n"}}
+        if len(tests) != 1 || tests[0] != want[0] {
+            t.Errorf("%s: parsed %q, want %q", c.name, tests, want)
+        }
+    }
+}
+
+func TestParseTestsMissing(t *testing.T) {
+    pages := []string{
+        `<div class="problem-statement"></div>`,
+        `<div class="sample-tests"><div class="sample-test"><div class="input"><pre>1</pre></div></div></div>`,
+    }
+    for _, page := range pages {
+        doc, err := html.Parse(strings.NewReader(page))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if tests, err := parseTests(doc); err == nil {
+            t.Errorf("parseTests(%s) = %q, want error", page, tests)
+        }
+    }
+}
+
+func readFixture(t *testing.T, path string) *html.Node {
+    t.Helper()
+    f, err := os.Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    doc, err := html.Parse(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return doc
+}
+
+// normalizes line endings for comparing against older sample files,
+// which lack the trailing newline
+func trimLines(s string) string {
+    return strings.TrimRight(s, "\n")
+}
@@ -0,0 +1,50 @@
+package workspace
+
+import (
+    "sync"
+    "time"
+)
+
+// token bucket allowing bursts of up to burst requests
+// refilled at one token every interval
+type RateLimiter struct {
+    mu       sync.Mutex
+    interval time.Duration
+    burst    int
+    tokens   float64
+    last     time.Time
+}
+
+// returns a full rate limiter
+func NewRateLimiter(interval time.Duration, burst int) *RateLimiter {
+    if burst < 1 {
+        burst = 1
+    }
+    return &RateLimiter{interval: interval, burst: burst, tokens: float64(burst), last: time.Now()}
+}
+
+// blocks until a token is available and takes it
+func (l *RateLimiter) Wait() {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    now := time.Now()
+    if l.interval > 0 {
+        l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+    } else {
+        l.tokens = float64(l.burst)
+    }
+    if l.tokens > float64(l.burst) {
+        l.tokens = float64(l.burst)
+    }
+    l.last = now
+
+    if l.tokens < 1 {
+        // sleep holding the lock so waiters are served in turn
+        wait := time.Duration((1 - l.tokens) * float64(l.interval))
+        time.Sleep(wait)
+        l.tokens = 1
+        l.last = now.Add(wait)
+    }
+    l.tokens--
+}
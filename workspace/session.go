@@ -0,0 +1,139 @@
+package workspace
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// Types for session data stored in ~/.config/forces/session.json
+// Used to store:
+//   1) path to test cases and solution files
+//   2) contest progress and solution verdicts 
+// Persists until the next call to "forces train "
+
+//TODO: start time 
+
+type Session struct {
+    Path      string
+    Source    Source
+    Problems  []ProblemState
+}
+
+// !ok when problem not found 
+func (s Session) ProblemById(id string) (ProblemState, bool) {
+    for _, p := range s.Problems {
+        if p.Id() == id {
+            return p, true
+        }
+    }
+    return ProblemState{}, false
+}
+
+// replaces the problem state with the same solution file as p
+// !ok when problem not found
+func (s *Session) SetProblem(p ProblemState) bool {
+    for i := range s.Problems {
+        if s.Problems[i].FileName == p.FileName {
+            s.Problems[i] = p
+            return true
+        }
+    }
+    return false
+}
+
+// returns most recently modified problem from the current session
+func (s Session) ProblemRecent() (ProblemState, error) {
+    if len(s.Problems) == 0 {
+        return ProblemState{}, fmt.Errorf("Can't get current problem. No problems listed for current session.")
+    }
+    dir := s.Path
+    // find problem with the largest unix modification time
+    var lastModified ProblemState
+    var maxModTime int64
+    for _, p := range s.Problems {
+        path := filepath.Join(dir, p.FileName)
+        info, err := os.Stat(path)
+        if err != nil {
+            return ProblemState{}, err
+        }
+        if t := info.ModTime().Unix(); t > maxModTime {
+            maxModTime   = t
+            lastModified = p
+        }
+    }
+    return lastModified, nil
+}
+
+// directory holding the sample tests of problem id
+func (s Session) TestDir(id string) string {
+    return filepath.Join(s.Path, "tests", id)
+}
+
+// active template and test/submission verdicts for problem w/ id = problemId
+// TODO: Template  TemplateName -> Templ Template
+// adds redundancy but decouples Session and TemplateRegistry structs
+type ProblemState struct {
+    FileName      string
+    Name          string
+    Url           string
+    Template      TemplateName
+    Tests         TestVerdict
+    Submission    SubmitVerdict
+    Checker       string // output comparator spec, e.g. "tokens" or "float:1e-6"
+    CheckerSource string // checker program in tests/{problemId}, overrides Checker
+    Interactive   bool
+    Interactor    string // path to interactor binary for interactive problems
+    TimeLimit     time.Duration
+    MemoryLimit   int64 // bytes
+    Cursor        int   // line of the {{cursor}} marker in the solution, 0 if none
+}
+
+// problem id of the solution file (file name without extension)
+func (p ProblemState) Id() string {
+    return strings.Split(p.FileName, ".")[0]
+}
+
+type TestVerdict struct {
+    Passed    int // num
+    Total     int // den
+}
+
+type SubmitVerdict struct {
+    Label   SVLabel
+    Message string
+}
+
+type SVLabel uint8
+const (
+    NA                      SVLabel = iota
+    MemoryLimitExceeded 
+    TimeLimitExceeded
+    RuntimeError
+    WrongAnswer
+    IdlenessLimitExceeded
+    DenialOfJudgement
+    Accepted
+)
+
+func (l SVLabel) String() string {
+    switch l {
+    case MemoryLimitExceeded:
+        return "memory limit exceeded"
+    case TimeLimitExceeded:
+        return "time limit exceeded"
+    case RuntimeError:
+        return "runtime error"
+    case WrongAnswer:
+        return "wrong answer"
+    case IdlenessLimitExceeded:
+        return "idleness limit exceeded"
+    case DenialOfJudgement:
+        return "denial of judgement"
+    case Accepted:
+        return "accepted"
+    }
+    return "n/a"
+}
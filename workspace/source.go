@@ -0,0 +1,60 @@
+package workspace
+
+import (
+    "fmt"
+)
+
+// kind of page a contest is trained from
+// codeforces has contests, gyms, groups and the problemset
+type SourceKind string
+
+const (
+    ContestSource    SourceKind = "contest"    // /contest/1336
+    GymSource        SourceKind = "gym"        // /gym/102951
+    GroupSource      SourceKind = "group"      // /group/MWSDmqGsZm/contest/219158
+    ProblemsetSource SourceKind = "problemset" // /problemset/problem/1336/A
+)
+
+// Source identifies a contest of a judge and the page layout its urls follow
+// The zero Judge and Kind are codeforces and ContestSource so older
+// sessions keep working.
+type Source struct {
+    Judge   string
+    Kind    SourceKind
+    Group   string // group id, codeforces GroupSource only
+    Contest string
+}
+
+func (s Source) kind() SourceKind {
+    if s.Kind == "" {
+        return ContestSource
+    }
+    return s.Kind
+}
+
+func (s Source) judge() string {
+    if s.Judge == "" {
+        return CodeforcesJudge
+    }
+    return s.Judge
+}
+
+// typed id of s, e.g. gym:102951 or atcoder:abc300
+func (s Source) String() string {
+    switch {
+    case s.judge() != CodeforcesJudge:
+        return fmt.Sprintf("%s:%s", s.judge(), s.Contest)
+    case s.kind() == GroupSource:
+        return fmt.Sprintf("group:%s/%s", s.Group, s.Contest)
+    }
+    return fmt.Sprintf("%s:%s", s.kind(), s.Contest)
+}
+
+// name of the directory the contest is trained in
+// group contest ids are only unique within their group
+func (s Source) Dir() string {
+    if s.Group != "" {
+        return s.Group + "-" + s.Contest
+    }
+    return s.Contest
+}
@@ -0,0 +1,58 @@
+package workspace
+
+import (
+    "fmt"
+    "strings"
+)
+
+// true if no section of the statement was scraped
+func (s Statement) empty() bool {
+    return s.Legend == "" && s.Constraints == "" && s.Input == "" && s.Output == "" && s.Interaction == "" && s.Note == ""
+}
+
+// renders problem p as a markdown document:
+// title, limits, legend, constraints, input/output (or interaction),
+// examples, note, tags
+func renderStatement(p Problem) []byte {
+    var b strings.Builder
+    fmt.Fprintf(&b, "# %s\n\n", p.Name)
+    if p.TimeLimit > 0 || p.MemoryLimit > 0 {
+        fmt.Fprintf(&b, "time limit per test: %v  \nmemory limit per test: %d megabytes\n\n", p.TimeLimit, p.MemoryLimit>>20)
+    }
+    if p.Url != "" {
+        fmt.Fprintf(&b, "<%s>\n\n", p.Url)
+    }
+
+    s := p.Statement
+    sections := []struct{ title, body string }{
+        {"", s.Legend},
+        {"Constraints", s.Constraints},
+        {"Input", s.Input},
+        {"Output", s.Output},
+        {"Interaction", s.Interaction},
+    }
+    for _, sec := range sections {
+        if sec.body == "" {
+            continue
+        }
+        if sec.title != "" {
+            fmt.Fprintf(&b, "## %s\n\n", sec.title)
+        }
+        fmt.Fprintf(&b, "%s\n\n", sec.body)
+    }
+
+    if len(p.Tests) > 0 {
+        b.WriteString("## Examples\n\n")
+        for _, t := range p.Tests {
+            fmt.Fprintf(&b, "Input\n\n```\n%s\n```\n\n", strings.TrimRight(t.Input, "\n"))
+            fmt.Fprintf(&b, "Output\n\n```\n%s\n```\n\n", strings.TrimRight(t.Output, "\n"))
+        }
+    }
+    if s.Note != "" {
+        fmt.Fprintf(&b, "## Note\n\n%s\n\n", s.Note)
+    }
+    if len(s.Tags) > 0 {
+        fmt.Fprintf(&b, "Tags: %s\n", strings.Join(s.Tags, ", "))
+    }
+    return []byte(strings.TrimRight(b.String(), "\n") + "\n")
+}
@@ -0,0 +1,364 @@
+package workspace
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "text/template"
+    "time"
+)
+
+// Types for template data stored in ~/.config/forces/templates.json
+type TemplateName string
+type TemplateRegistry struct {
+    Starter    TemplateName
+    List       []Template
+    Toolchains []Toolchain `json:",omitempty"` // user toolchains, see toolchain.go
+}
+
+type Template struct {
+    Name      TemplateName
+    Path      string
+    Ext       string
+    Toolchain ToolchainName
+    Run       string `json:",omitempty"` // legacy run command, migrated to a toolchain on read
+}
+
+func (t TemplateRegistry) GetStarter() (Template, bool) {
+    for _, templ := range t.List {
+        if templ.Name == t.Starter {
+            return templ, true
+        }
+    }
+    return Template{}, false
+}
+
+// !ok when no template named name is registered
+func (t TemplateRegistry) GetTemplate(name TemplateName) (Template, bool) {
+    for _, templ := range t.List {
+        if templ.Name == name {
+            return templ, true
+        }
+    }
+    return Template{}, false
+}
+
+// returns the first template with file extension ext
+func (t TemplateRegistry) TemplateForExt(ext string) (Template, bool) {
+    for _, templ := range t.List {
+        if templ.Ext == ext {
+            return templ, true
+        }
+    }
+    return Template{}, false
+}
+
+// checks a template before it's registered:
+// it has a name, its file exists and its extension matches Ext
+func (t Template) Validate() error {
+    if strings.TrimSpace(string(t.Name)) == "" {
+        return fmt.Errorf("template has no name")
+    }
+    if strings.ContainsAny(string(t.Name), " \t\n") {
+        return fmt.Errorf("template name %q contains whitespace", t.Name)
+    }
+    info, err := os.Stat(t.Path)
+    if err != nil {
+        return fmt.Errorf("template %s: %w", t.Name, err)
+    }
+    if info.IsDir() {
+        return fmt.Errorf("template %s: %s is a directory", t.Name, t.Path)
+    }
+    if ext := filepath.Ext(t.Path); ext != t.Ext {
+        return fmt.Errorf("template %s: extension %q doesn't match %s", t.Name, t.Ext, t.Path)
+    }
+    if t.Toolchain == "" {
+        return fmt.Errorf("template %s has no toolchain", t.Name)
+    }
+    return nil
+}
+
+// checks that template and toolchain names are unique, the starter exists
+// and every template's toolchain is known and builds its extension.
+// template files aren't checked so broken templates can still be removed
+func (t TemplateRegistry) Validate() error {
+    toolchains := make(map[ToolchainName]bool)
+    for _, c := range t.Toolchains {
+        if err := c.Validate(); err != nil {
+            return err
+        }
+        if toolchains[c.Name] {
+            return fmt.Errorf("toolchain name %s is used more than once", c.Name)
+        }
+        toolchains[c.Name] = true
+    }
+    seen := make(map[TemplateName]bool)
+    for _, templ := range t.List {
+        if templ.Name == "" {
+            return fmt.Errorf("template %s has no name", templ.Path)
+        }
+        if seen[templ.Name] {
+            return fmt.Errorf("template name %s is used more than once", templ.Name)
+        }
+        seen[templ.Name] = true
+        c, err := t.ToolchainFor(templ)
+        if err != nil {
+            return err
+        }
+        if c.Ext != "" && c.Ext != templ.Ext {
+            return fmt.Errorf("template %s is a %s file but toolchain %s builds %s files", templ.Name, templ.Ext, c.Name, c.Ext)
+        }
+    }
+    if _, ok := t.GetStarter(); !ok {
+        return fmt.Errorf("starter template %s isn't registered", t.Starter)
+    }
+    return nil
+}
+
+// registers templ, the starter if it's the only template
+// Ext defaults to the extension of Path and Toolchain to DefaultToolchain(Ext).
+func (t *TemplateRegistry) Add(templ Template) (Template, error) {
+    if _, err := os.Stat(templ.Path); err != nil {
+        return Template{}, err
+    }
+    if templ.Ext == "" {
+        templ.Ext = filepath.Ext(templ.Path)
+    }
+    if templ.Toolchain == "" {
+        name, ok := DefaultToolchain(templ.Ext)
+        if !ok {
+            return Template{}, fmt.Errorf("no default toolchain for %q templates, give one", templ.Ext)
+        }
+        templ.Toolchain = name
+    }
+    if err := templ.Validate(); err != nil {
+        return Template{}, err
+    }
+    if _, ok := t.GetTemplate(templ.Name); ok {
+        return Template{}, fmt.Errorf("template %s already exists", templ.Name)
+    }
+    c, err := t.ToolchainFor(templ)
+    if err != nil {
+        return Template{}, err
+    }
+    if c.Ext != "" && c.Ext != templ.Ext {
+        return Template{}, fmt.Errorf("toolchain %s builds %s files, not %s", c.Name, c.Ext, templ.Ext)
+    }
+    t.List = append(t.List, templ)
+    if len(t.List) == 1 {
+        t.Starter = templ.Name
+    }
+    return templ, nil
+}
+
+// unregisters the template named name, which can't be the starter
+func (t *TemplateRegistry) Remove(name TemplateName) error {
+    for i, templ := range t.List {
+        if templ.Name != name {
+            continue
+        }
+        if name == t.Starter {
+            return fmt.Errorf("template %s is the starter, choose another starter first", name)
+        }
+        t.List = append(t.List[:i], t.List[i+1:]...)
+        return nil
+    }
+    return fmt.Errorf("template %s not found", name)
+}
+
+// makes the template named name the starter for new solutions
+func (t *TemplateRegistry) SetStarter(name TemplateName) error {
+    if _, ok := t.GetTemplate(name); !ok {
+        return fmt.Errorf("template %s not found", name)
+    }
+    t.Starter = name
+    return nil
+}
+
+// validates r and replaces the registry at path p in one step, so an
+// interrupted write never leaves a truncated templates.json
+func WriteTemplateRegistry(p string, r TemplateRegistry) error {
+    if err := r.Validate(); err != nil {
+        return err
+    }
+    dat, err := json.MarshalIndent(&r, "", "    ")
+    if err != nil {
+        return err
+    }
+    return writeFileAtomic(p, dat)
+}
+
+// returns deserialized TemplateRegistry data read from path p (appDir/templates.json)
+// templates with legacy run commands are migrated to toolchains and saved
+func ReadTemplateRegistry(p string) (TemplateRegistry, error) {
+    var r TemplateRegistry
+    if err := readJSON(p, &r); err != nil {
+        return TemplateRegistry{}, err
+    }
+    if r.migrate() {
+        if err := WriteTemplateRegistry(p, r); err != nil {
+            return TemplateRegistry{}, fmt.Errorf("migrating %s: %w", p, err)
+        }
+    }
+    return r, nil
+}
+
+// returns new TemplateRegistry struct after serializing to path p (appDir/templates.json)
+// also restores default.cpp template if doesn't exist
+func InitTemplateRegistry(p string) (TemplateRegistry, error) {
+
+    cppPath := filepath.Join(filepath.Dir(p), "default.cpp")
+    if _, err := os.Stat(cppPath); err != nil {
+        // create a new default.cpp template
+        if err := InitDefaultTemplate(cppPath); err != nil {
+            return TemplateRegistry{}, err
+        }
+    }
+
+    init := Template{
+        Name: "default",
+        Path: cppPath,
+        Ext: ".cpp",
+        Toolchain: "cpp17",
+    }
+    r := TemplateRegistry{Starter: "default", List: []Template{init}}
+
+    if err := WriteTemplateRegistry(p, r); err != nil {
+        return TemplateRegistry{}, err
+    }
+    return r, nil
+}
+
+
+func InitDefaultTemplate(p string) error {
+    cpp := `
+Test CPP file
+
+`
+    return os.WriteFile(p, []byte(cpp), 0644)
+}
+
+
+// values available to template files, e.g. {{.Name}}
+type SolutionData struct {
+    ContestID   string
+    ProblemID   string
+    Name        string
+    URL         string
+    Judge       string
+    Date        string // 2006-01-02 15:04
+    TimeLimit   time.Duration
+    MemoryLimit int64 // megabytes
+    Author      string
+}
+
+// returns the template data of problem p of contest c
+func solutionData(c Contest, p Problem, author string) SolutionData {
+    return SolutionData{
+        ContestID:   c.Id,
+        ProblemID:   p.Id,
+        Name:        p.Name,
+        URL:         p.Url,
+        Judge:       c.source().judge(),
+        Date:        time.Now().Format("2006-01-02 15:04"),
+        TimeLimit:   p.TimeLimit,
+        MemoryLimit: p.MemoryLimit >> 20,
+        Author:      author,
+    }
+}
+
+// line comment syntax of a language, suffix is set for languages that
+// only have block comments
+type commentStyle struct {
+    prefix string
+    suffix string
+}
+
+// comment syntax by template extension, "//" for anything else
+var commentStyles = map[string]commentStyle{
+    ".py":  {"# ", ""},
+    ".rb":  {"# ", ""},
+    ".sh":  {"# ", ""},
+    ".pl":  {"# ", ""},
+    ".jl":  {"# ", ""},
+    ".r":   {"# ", ""},
+    ".nim": {"# ", ""},
+    ".hs":  {"-- ", ""},
+    ".lua": {"-- ", ""},
+    ".sql": {"-- ", ""},
+    ".ml":  {"(* ", " *)"},
+    ".pas": {"{ ", " }"},
+}
+
+func commentStyleFor(ext string) commentStyle {
+    if c, ok := commentStyles[strings.ToLower(ext)]; ok {
+        return c
+    }
+    return commentStyle{"// ", ""}
+}
+
+// comments out every line of s
+func (c commentStyle) comment(s string) string {
+    lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+    for i, line := range lines {
+        lines[i] = strings.TrimRight(c.prefix + line + c.suffix, " ")
+    }
+    return strings.Join(lines, "\n")
+}
+
+// header prepended to template files without template actions
+func (d SolutionData) header() string {
+    header := fmt.Sprintf("contest: %s\nproblem name: %s\nurl: %s\ndate: %s", d.ContestID, d.Name, d.URL, d.Date)
+    if d.Author != "" {
+        header += "\nauthor: " + d.Author
+    }
+    return header
+}
+
+// marks the {{cursor}} position while rendering, removed afterwards
+const cursorMark = "\x00forces-cursor\x00"
+
+// renders the solution file of template t:
+// files using template actions are executed with text/template and data,
+// e.g. {{.Name}}, {{.URL}}, {{comment "text"}}, {{header}} and {{cursor}}.
+// Other files (or files that don't parse as templates, say C++ with
+// brace initialization like {{1, 2}}) get the default header commented in
+// the language of t prepended. Returns the 1-based line of {{cursor}},
+// 0 if the template has none, or the error executing the template.
+func renderSolution(t Template, data SolutionData) ([]byte, int, error) {
+    src, err := os.ReadFile(t.Path)
+    if err != nil {
+        return nil, 0, err
+    }
+    style := commentStyleFor(t.Ext)
+    fallback := []byte(style.comment(data.header()) + "\n\n" + string(src))
+
+    if !strings.Contains(string(src), "{{") {
+        return fallback, 0, nil
+    }
+    funcs := template.FuncMap{
+        "comment": style.comment,
+        "header":  func() string { return style.comment(data.header()) },
+        "cursor":  func() string { return cursorMark },
+    }
+    templ, err := template.New(string(t.Name)).Funcs(funcs).Parse(string(src))
+    if err != nil {
+        return fallback, 0, nil
+    }
+    // a template that parses was meant as one, so mistakes like an unknown
+    // field are reported rather than written out as plain text
+    var b strings.Builder
+    if err := templ.Execute(&b, data); err != nil {
+        return nil, 0, fmt.Errorf("rendering template %s: %w", t.Name, err)
+    }
+
+    out := b.String()
+    line := 0
+    if i := strings.Index(out, cursorMark); i >= 0 {
+        line = strings.Count(out[:i], "\n") + 1
+        out = strings.ReplaceAll(out, cursorMark, "")
+    }
+    return []byte(out), line, nil
+}
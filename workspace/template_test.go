@@ -0,0 +1,185 @@
+package workspace
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestRenderSolution(t *testing.T) {
+    data := SolutionData{
+        ContestID: "1336",
+        ProblemID: "A",
+        Name:      "Linova and Kingdom",
+        URL:       "https://codeforces.com/contest/1336/problem/A",
+        Date:      "2020-04-15 17:35",
+        TimeLimit: 2 * time.Second,
+        Author:    "tourist",
+    }
+    tests := []struct {
+        name   string
+        ext    string
+        templ  string
+        want   string
+        cursor int
+    }{
+        {
+            name:  "plain cpp gets header",
+            ext:   ".cpp",
+            templ: "int main() {}\n",
+            want:  "// contest: 1336\n// problem name: Linova and Kingdom\n// url: https://codeforces.com/contest/1336/problem/A\n// date: 2020-04-15 17:35\n// author: tourist\n\nint main() {}\n",
+        },
+        {
+            name:   "python variables and cursor",
+            ext:    ".py",
+            templ:  "{{comment .Name}}\n{{comment .TimeLimit.String}}\ndef main():\n    {{cursor}}pass\n",
+            want:   "# Linova and Kingdom\n# 2s\ndef main():\n    pass\n",
+            cursor: 4,
+        },
+        {
+            name:  "header action",
+            ext:   ".hs",
+            templ: "{{header}}\nmain = return ()\n",
+            want:  "-- contest: 1336\n-- problem name: Linova and Kingdom\n-- url: https://codeforces.com/contest/1336/problem/A\n-- date: 2020-04-15 17:35\n-- author: tourist\nmain = return ()\n",
+        },
+        {
+            name:  "brace init falls back to plain text",
+            ext:   ".cpp",
+            templ: "vector<pair<int, int>> v{{1, 2}};\n",
+            want:  "// contest: 1336\n// problem name: Linova and Kingdom\n// url: https://codeforces.com/contest/1336/problem/A\n// date: 2020-04-15 17:35\n// author: tourist\n\nvector<pair<int, int>> v{{1, 2}};\n",
+        },
+    }
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            path := filepath.Join(t.TempDir(), "main" + test.ext)
+            if err := os.WriteFile(path, []byte(test.templ), 0644); err != nil {
+                t.Fatal(err)
+            }
+            got, cursor, err := renderSolution(Template{Name: "main", Path: path, Ext: test.ext}, data)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if string(got) != test.want {
+                t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+            }
+            if cursor != test.cursor {
+                t.Errorf("cursor on line %d, want %d", cursor, test.cursor)
+            }
+        })
+    }
+}
+
+func TestRenderSolutionExecuteError(t *testing.T) {
+    templs := []string{
+        "{{comment .Nmae}}\nint main() {}\n",
+        "{{.TimeLimit.Minutes 2}}\n",
+    }
+    for _, templ := range templs {
+        path := filepath.Join(t.TempDir(), "main.cpp")
+        if err := os.WriteFile(path, []byte(templ), 0644); err != nil {
+            t.Fatal(err)
+        }
+        got, _, err := renderSolution(Template{Name: "main", Path: path, Ext: ".cpp"}, SolutionData{Name: "x"})
+        if err == nil {
+            t.Errorf("%q rendered as %q, want an error", templ, got)
+        }
+    }
+}
+
+func TestTemplateRegistry(t *testing.T) {
+    dir := t.TempDir()
+    for _, name := range []string{"main.cpp", "fast.cpp", "sol.py", "notes.txt"} {
+        if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    path := func(name string) string { return filepath.Join(dir, name) }
+
+    var r TemplateRegistry
+    // the first template added becomes the starter
+    templ, err := r.Add(Template{Name: "main", Path: path("main.cpp")})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if templ.Ext != ".cpp" || templ.Toolchain != "cpp17" || r.Starter != "main" {
+        t.Errorf("added %+v with starter %s", templ, r.Starter)
+    }
+    if _, err := r.Add(Template{Name: "py", Path: path("sol.py"), Toolchain: "pypy3"}); err != nil {
+        t.Fatal(err)
+    }
+    if r.Starter != "main" {
+        t.Errorf("second template changed the starter to %s", r.Starter)
+    }
+
+    bad := []struct {
+        name  string
+        templ Template
+        err   string
+    }{
+        {"duplicate name", Template{Name: "main", Path: path("fast.cpp")}, "template main already exists"},
+        {"whitespace name", Template{Name: "my main", Path: path("fast.cpp")}, "contains whitespace"},
+        {"empty name", Template{Name: " ", Path: path("fast.cpp")}, "has no name"},
+        {"toolchain for another ext", Template{Name: "fast", Path: path("fast.cpp"), Toolchain: "python3"}, "toolchain python3 builds .py files, not .cpp"},
+        {"ext not matching path", Template{Name: "fast", Path: path("fast.cpp"), Ext: ".cc", Toolchain: "cpp17"}, "doesn't match"},
+        {"unknown toolchain", Template{Name: "fast", Path: path("fast.cpp"), Toolchain: "cpp99"}, `unknown toolchain "cpp99"`},
+        {"no default toolchain", Template{Name: "notes", Path: path("notes.txt")}, `no default toolchain for ".txt"`},
+        {"missing file", Template{Name: "gone", Path: path("gone.cpp")}, "no such file"},
+    }
+    for _, c := range bad {
+        if _, err := r.Add(c.templ); err == nil || !strings.Contains(err.Error(), c.err) {
+            t.Errorf("%s: error %v, want %q", c.name, err, c.err)
+        }
+    }
+    if len(r.List) != 2 {
+        t.Errorf("failed adds changed the list: %+v", r.List)
+    }
+    if err := r.Validate(); err != nil {
+        t.Errorf("Validate: %v", err)
+    }
+
+    // the starter can't be removed until another is chosen
+    if err := r.Remove("main"); err == nil || !strings.Contains(err.Error(), "is the starter") {
+        t.Errorf("removing the starter: %v", err)
+    }
+    if err := r.SetStarter("missing"); err == nil || r.Starter != "main" {
+        t.Errorf("SetStarter(missing) = %v, starter %s", err, r.Starter)
+    }
+    if err := r.SetStarter("py"); err != nil {
+        t.Fatal(err)
+    }
+    if err := r.Remove("main"); err != nil {
+        t.Fatal(err)
+    }
+    if err := r.Remove("main"); err == nil {
+        t.Error("removed main twice")
+    }
+    if len(r.List) != 1 || r.List[0].Name != "py" {
+        t.Errorf("list after remove %+v", r.List)
+    }
+}
+
+func TestTemplateRegistryValidate(t *testing.T) {
+    cpp := Template{Name: "main", Path: "main.cpp", Ext: ".cpp", Toolchain: "cpp17"}
+    py  := Template{Name: "py", Path: "sol.py", Ext: ".py", Toolchain: "python3"}
+    cases := []struct {
+        name string
+        r    TemplateRegistry
+        err  string
+    }{
+        {"valid", TemplateRegistry{Starter: "main", List: []Template{cpp, py}}, ""},
+        {"duplicate name", TemplateRegistry{Starter: "main", List: []Template{cpp, cpp}}, "used more than once"},
+        {"missing starter", TemplateRegistry{Starter: "gone", List: []Template{cpp}}, "starter template gone isn't registered"},
+        {"ext mismatch", TemplateRegistry{Starter: "main", List: []Template{{Name: "main", Path: "main.cpp", Ext: ".cpp", Toolchain: "python3"}}}, "is a .cpp file but toolchain python3 builds .py files"},
+        {"unknown toolchain", TemplateRegistry{Starter: "main", List: []Template{{Name: "main", Path: "main.cpp", Ext: ".cpp", Toolchain: "cpp99"}}}, "unknown toolchain"},
+        {"no name", TemplateRegistry{Starter: "main", List: []Template{cpp, {Path: "x.cpp", Ext: ".cpp", Toolchain: "cpp17"}}}, "has no name"},
+        {"duplicate toolchain", TemplateRegistry{Starter: "main", List: []Template{cpp}, Toolchains: []Toolchain{{Name: "mine", Execute: "./a"}, {Name: "mine", Execute: "./b"}}}, "toolchain name mine is used more than once"},
+    }
+    for _, c := range cases {
+        err := c.r.Validate()
+        if c.err == "" && err != nil || c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+            t.Errorf("%s: error %v, want %q", c.name, err, c.err)
+        }
+    }
+}
@@ -0,0 +1,183 @@
+package workspace
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Types for toolchains: how solutions in a language are built and run.
+// Commands are run by sh in a scratch work directory with placeholders
+//   {{source}} path of the source file
+//   {{binary}} path of the compiled program in the work directory
+//   {{dir}}    the work directory
+//   {{path}}   source path without extension (legacy run commands)
+type ToolchainName string
+type Toolchain struct {
+    Name    ToolchainName
+    Ext     string // extension of source files, empty for any
+    Compile string // empty for interpreted languages
+    Execute string
+}
+
+// toolchains every registry knows, user toolchains of the same name win
+var builtinToolchains = []Toolchain{
+    {Name: "cpp14", Ext: ".cpp", Compile: "g++ -std=c++14 -O2 -o {{binary}} {{source}}", Execute: "{{binary}}"},
+    {Name: "cpp17", Ext: ".cpp", Compile: "g++ -std=c++17 -O2 -o {{binary}} {{source}}", Execute: "{{binary}}"},
+    {Name: "cpp20", Ext: ".cpp", Compile: "g++ -std=c++20 -O2 -o {{binary}} {{source}}", Execute: "{{binary}}"},
+    {Name: "c11", Ext: ".c", Compile: "gcc -std=c11 -O2 -o {{binary}} {{source}} -lm", Execute: "{{binary}}"},
+    // javac wants the file named after its public class, so solutions declare class Main
+    {Name: "java", Ext: ".java", Compile: "cp {{source}} {{dir}}/Main.java && javac -encoding UTF-8 -d {{dir}} {{dir}}/Main.java", Execute: "java -Xss64m -cp {{dir}} Main"},
+    {Name: "python3", Ext: ".py", Execute: "python3 {{source}}"},
+    {Name: "pypy3", Ext: ".py", Execute: "pypy3 {{source}}"},
+    {Name: "go", Ext: ".go", Compile: "go build -o {{binary}} {{source}}", Execute: "{{binary}}"},
+    {Name: "rust", Ext: ".rs", Compile: "rustc --edition 2021 -O -o {{binary}} {{source}}", Execute: "{{binary}}"},
+    {Name: "kotlin", Ext: ".kt", Compile: "kotlinc {{source}} -include-runtime -d {{binary}}.jar", Execute: "java -Xss64m -jar {{binary}}.jar"},
+}
+
+// toolchain of templates added without one, by file extension
+var defaultToolchains = map[string]ToolchainName{
+    ".cpp":  "cpp17",
+    ".c":    "c11",
+    ".java": "java",
+    ".py":   "python3",
+    ".go":   "go",
+    ".rs":   "rust",
+    ".kt":   "kotlin",
+}
+
+// returns the toolchain used for templates with extension ext
+func DefaultToolchain(ext string) (ToolchainName, bool) {
+    name, ok := defaultToolchains[ext]
+    return name, ok
+}
+
+// returns the built-in toolchains
+func BuiltinToolchains() []Toolchain {
+    return append([]Toolchain(nil), builtinToolchains...)
+}
+
+// checks a toolchain has a name and something to execute
+func (c Toolchain) Validate() error {
+    if strings.TrimSpace(string(c.Name)) == "" {
+        return fmt.Errorf("toolchain has no name")
+    }
+    if strings.TrimSpace(c.Execute) == "" {
+        return fmt.Errorf("toolchain %s has no execute command", c.Name)
+    }
+    return nil
+}
+
+// returns the user toolchain named name, falling back to the built-in one
+func (t TemplateRegistry) GetToolchain(name ToolchainName) (Toolchain, bool) {
+    for _, c := range t.Toolchains {
+        if c.Name == name {
+            return c, true
+        }
+    }
+    for _, c := range builtinToolchains {
+        if c.Name == name {
+            return c, true
+        }
+    }
+    return Toolchain{}, false
+}
+
+// returns the toolchain building solutions of template templ
+func (t TemplateRegistry) ToolchainFor(templ Template) (Toolchain, error) {
+    c, ok := t.GetToolchain(templ.Toolchain)
+    if !ok {
+        return Toolchain{}, fmt.Errorf("template %s uses unknown toolchain %q", templ.Name, templ.Toolchain)
+    }
+    return c, nil
+}
+
+// returns the toolchain for source files with extension ext: that of the
+// first template with ext, otherwise the default for ext
+func (t TemplateRegistry) ToolchainForExt(ext string) (Toolchain, bool) {
+    if templ, ok := t.TemplateForExt(ext); ok {
+        if c, err := t.ToolchainFor(templ); err == nil {
+            return c, true
+        }
+    }
+    if name, ok := DefaultToolchain(ext); ok {
+        return t.GetToolchain(name)
+    }
+    return Toolchain{}, false
+}
+
+// legacy run commands with an equivalent built-in toolchain
+var legacyRuns = map[string]ToolchainName{
+    "g++ -o sol {{path}}.cpp & ./sol":      "cpp17",
+    "g++ -O2 -o sol {{path}}.cpp && ./sol": "cpp17",
+    "gcc -O2 -o sol {{path}}.c && ./sol":   "c11",
+    "python3 {{path}}.py":                  "python3",
+    "go build -o sol {{path}}.go && ./sol": "go",
+}
+
+// moves templates from legacy run commands of the form "<build> && <exec>"
+// to toolchains, adding a toolchain named after the template unless a
+// built-in one does the same. Reports whether anything changed.
+func (t *TemplateRegistry) migrate() bool {
+    changed := false
+    for i, templ := range t.List {
+        if templ.Run == "" {
+            continue
+        }
+        changed = true
+        t.List[i].Run = ""
+        if templ.Toolchain != "" {
+            continue
+        }
+        if name, ok := legacyRuns[strings.TrimSpace(templ.Run)]; ok {
+            t.List[i].Toolchain = name
+            continue
+        }
+        name := ToolchainName(templ.Name)
+        if _, ok := t.GetToolchain(name); ok {
+            name += "-run"
+        }
+        compile, execute := splitRun(templ.Run)
+        t.Toolchains = append(t.Toolchains, Toolchain{Name: name, Ext: templ.Ext, Compile: compile, Execute: execute})
+        t.List[i].Toolchain = name
+    }
+    return changed
+}
+
+// splits a legacy run command of the form "<build> && <exec>" into
+// its build and exec halves at the last "&&" (or single "&") separating
+// commands, so builds may chain commands themselves. "&" in redirections
+// like 2>&1 or &>file and inside quotes doesn't separate anything.
+// Commands without a separator (e.g. "python3 {{path}}.py") have no build step.
+func splitRun(run string) (build, exec string) {
+    sep, end := -1, -1
+    var quote byte
+    for i := 0; i < len(run); i++ {
+        c := run[i]
+        switch {
+        case quote != 0:
+            if c == quote {
+                quote = 0
+            } else if c == '\\' && quote == '"' {
+                i++
+            }
+        case c == '\\':
+            i++
+        case c == '\'' || c == '"':
+            quote = c
+        case c != '&':
+        case i > 0 && (run[i-1] == '>' || run[i-1] == '<' || run[i-1] == '|'):
+            // >&2, <&0, |&
+        case i+1 < len(run) && run[i+1] == '>':
+            // &>file, &>>file
+        case i+1 < len(run) && run[i+1] == '&':
+            sep, end = i, i+2
+            i++
+        default:
+            sep, end = i, i+1
+        }
+    }
+    if sep < 0 {
+        return "", strings.TrimSpace(run)
+    }
+    return strings.TrimSpace(run[:sep]), strings.TrimSpace(run[end:])
+}
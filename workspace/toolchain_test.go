@@ -0,0 +1,69 @@
+package workspace
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestMigrateRun(t *testing.T) {
+    r := TemplateRegistry{
+        Starter: "default",
+        List: []Template{
+            {Name: "default", Path: "default.cpp", Ext: ".cpp", Run: "g++ -o sol {{path}}.cpp & ./sol"},
+            {Name: "fast", Path: "fast.cpp", Ext: ".cpp", Run: "clang++ -O3 -o sol {{path}}.cpp && ./sol"},
+            {Name: "go", Path: "go.go", Ext: ".go", Run: "go run {{path}}.go"},
+            {Name: "main", Path: "main.py", Ext: ".py", Toolchain: "pypy3"},
+            {Name: "verbose", Path: "verbose.cpp", Ext: ".cpp", Run: "g++ -o sol {{path}}.cpp 2>&1 && ./sol 2>&1"},
+            {Name: "chain", Path: "chain.cpp", Ext: ".cpp", Run: "mkdir -p bin && g++ -o bin/sol {{path}}.cpp &>/dev/null && ./bin/sol"},
+        },
+    }
+    if !r.migrate() {
+        t.Fatal("registry with run commands wasn't migrated")
+    }
+    toolchains := []ToolchainName{"cpp17", "fast", "go-run", "pypy3", "verbose", "chain"}
+    for i, templ := range r.List {
+        if templ.Run != "" {
+            t.Errorf("template %s keeps run command %q", templ.Name, templ.Run)
+        }
+        if templ.Toolchain != toolchains[i] {
+            t.Errorf("template %s uses toolchain %s, want %s", templ.Name, templ.Toolchain, toolchains[i])
+        }
+    }
+    want := []Toolchain{
+        {Name: "fast", Ext: ".cpp", Compile: "clang++ -O3 -o sol {{path}}.cpp", Execute: "./sol"},
+        {Name: "go-run", Ext: ".go", Execute: "go run {{path}}.go"},
+        {Name: "verbose", Ext: ".cpp", Compile: "g++ -o sol {{path}}.cpp 2>&1", Execute: "./sol 2>&1"},
+        {Name: "chain", Ext: ".cpp", Compile: "mkdir -p bin && g++ -o bin/sol {{path}}.cpp &>/dev/null", Execute: "./bin/sol"},
+    }
+    if !reflect.DeepEqual(r.Toolchains, want) {
+        t.Errorf("toolchains %+v, want %+v", r.Toolchains, want)
+    }
+    if err := r.Validate(); err != nil {
+        t.Error(err)
+    }
+    if r.migrate() {
+        t.Error("migrated registry was migrated again")
+    }
+}
+
+func TestSplitRun(t *testing.T) {
+    cases := []struct {
+        run, build, exec string
+    }{
+        {"python3 {{path}}.py", "", "python3 {{path}}.py"},
+        {"g++ {{path}}.cpp && ./a.out", "g++ {{path}}.cpp", "./a.out"},
+        {"g++ {{path}}.cpp & ./a.out", "g++ {{path}}.cpp", "./a.out"},
+        {"g++ {{path}}.cpp 2>&1 && ./a.out", "g++ {{path}}.cpp 2>&1", "./a.out"},
+        {"./a.out 2>&1", "", "./a.out 2>&1"},
+        {"g++ {{path}}.cpp |& head && ./a.out >&2", "g++ {{path}}.cpp |& head", "./a.out >&2"},
+        {"cd bin && g++ ../{{path}}.cpp && ./a.out", "cd bin && g++ ../{{path}}.cpp", "./a.out"},
+        {"g++ -DX='a&&b' {{path}}.cpp && ./a.out \"&\"", "g++ -DX='a&&b' {{path}}.cpp", "./a.out \"&\""},
+        {"echo a\\&b", "", "echo a\\&b"},
+    }
+    for _, c := range cases {
+        build, exec := splitRun(c.run)
+        if build != c.build || exec != c.exec {
+            t.Errorf("splitRun(%q) = %q, %q, want %q, %q", c.run, build, exec, c.build, c.exec)
+        }
+    }
+}
@@ -0,0 +1,430 @@
+// Package workspace scrapes codeforces contests and lays them out on disk
+// as sample tests, starter solutions and a session tracking verdicts.
+package workspace
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sync"
+    "golang.org/x/net/html"
+)
+
+// Workspace is the set of directories a contest is trained in:
+//   Root/{contestDir}/{problemId}{ext}              starter solutions
+//   Root/{contestDir}/{problemId}.md                problem statements
+//   Root/{contestDir}/tests/{problemId}/in0.txt...  sample tests
+//   AppDir/session.json, AppDir/templates.json      session and templates
+// Pages are scraped through Fetcher from urls of the contest's Judge,
+// under BaseUrl (DefaultBaseUrl if empty) for codeforces, up to Workers (DefaultWorkers if 0) at a time.
+// Progress, if set, is called as each problem page downloads with the bytes
+// read so far and the page size (-1 if unknown); read == total once done.
+// Cache is the page cache used by Fetcher, if any.
+type Workspace struct {
+    Root     string
+    AppDir   string
+    BaseUrl  string
+    Fetcher  Fetcher
+    Cache    *Cache
+    Workers  int
+    Author   string // name rendered into solution templates
+    Progress func(problemId string, read, total int64)
+}
+
+// number of problem pages scraped concurrently by default
+const DefaultWorkers = 4
+
+// returns a workspace rooted at root using the default app dir and
+// fetching pages over http through the default page cache as configured
+// by its config.json
+func New(root string) (*Workspace, error) {
+    appDir, err := DefaultAppDir()
+    if err != nil {
+        return nil, err
+    }
+    config, err := ReadConfig(appDir)
+    if err != nil {
+        return nil, err
+    }
+    cache, err := config.Cache()
+    if err != nil {
+        return nil, err
+    }
+    fetcher, err := config.Fetcher(cache)
+    if err != nil {
+        return nil, err
+    }
+    return &Workspace{
+        Root:    root,
+        AppDir:  appDir,
+        BaseUrl: config.baseUrl(),
+        Fetcher: fetcher,
+        Cache:   cache,
+        Workers: config.Workers,
+        Author:  config.author(),
+    }, nil
+}
+
+// Store session data at os dependent config directory 
+// (e.g. .config/forces for linux).
+func DefaultAppDir() (string, error) {
+    configDir, err := os.UserConfigDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(configDir, "forces"), nil
+}
+
+// base url without a trailing slash
+func (w *Workspace) baseUrl() string {
+    return Config{BaseUrl: w.BaseUrl}.baseUrl()
+}
+
+// scrapes problems problemIds of contest src (all problems if none are
+// given), writes them to disk and replaces the current session
+func (w *Workspace) Train(src Source, problemIds []string) (Session, error) {
+    contest, err := w.ScrapeContest(src, problemIds)
+    if err != nil {
+        return Session{}, err
+    }
+    return w.Load(contest)
+}
+
+// scrapes problems problemIds of contest src (all problems if none are given)
+func (w *Workspace) ScrapeContest(src Source, problemIds []string) (Contest, error) {
+    judge, err := w.Judge(src.Judge)
+    if err != nil {
+        return Contest{}, err
+    }
+    // problem pages that can't be derived from their id
+    urls := make(map[string]string)
+    if len(problemIds) == 0 {
+        // get all problemIds from the contest page
+        html, err := w.Fetcher.Fetch(judge.ContestUrl(src))
+        if err != nil {
+            return Contest{}, err
+        }
+        problems, err := judge.ParseContest(html)
+        if err != nil {
+            return Contest{}, err
+        }
+        for _, p := range problems {
+            problemIds = append(problemIds, p.Id)
+            urls[p.Id] = p.Url
+        }
+    }
+
+    contest := Contest{
+        Id:       src.Contest,
+        Source:   src,
+        Problems: make([]Problem, len(problemIds)),
+    }
+    workers := w.Workers
+    if workers <= 0 {
+        workers = DefaultWorkers
+    }
+    if workers > len(problemIds) {
+        workers = len(problemIds)
+    }
+
+    // scrape problems with a bounded pool of workers
+    // problems keep their position, the first error wins
+    var (
+        wg       sync.WaitGroup
+        mu       sync.Mutex
+        firstErr error
+    )
+    jobs := make(chan int)
+    wg.Add(workers)
+    for i := 0; i < workers; i++ {
+        go func() {
+            defer wg.Done()
+            for j := range jobs {
+                problem, err := w.scrapeProblem(judge, src, problemIds[j], urls[problemIds[j]])
+                mu.Lock()
+                if err != nil && firstErr == nil {
+                    firstErr = err
+                }
+                contest.Problems[j] = problem
+                mu.Unlock()
+            }
+        }()
+    }
+    for j := range problemIds {
+        mu.Lock()
+        failed := firstErr != nil
+        mu.Unlock()
+        if failed {
+            break
+        }
+        jobs <- j
+    }
+    close(jobs)
+    wg.Wait()
+    if firstErr != nil {
+        return Contest{}, firstErr
+    }
+    return contest, nil
+}
+
+// scrapes the name, sample tests, limits and statement of a single problem
+func (w *Workspace) ScrapeProblem(src Source, problemId string) (Problem, error) {
+    judge, err := w.Judge(src.Judge)
+    if err != nil {
+        return Problem{}, err
+    }
+    return w.scrapeProblem(judge, src, problemId, "")
+}
+
+// scrapes problem problemId of src from url, or the judge's url for it if empty
+func (w *Workspace) scrapeProblem(judge Judge, src Source, problemId, url string) (Problem, error) {
+    if url == "" {
+        url = judge.ProblemUrl(src, problemId)
+    }
+    html, err := w.fetchProblem(problemId, url)
+    if err != nil {
+        return Problem{}, err
+    }
+    problem, err := judge.ParseProblem(html)
+    if err != nil {
+        return Problem{}, fmt.Errorf("problem %s: %w", problemId, err)
+    }
+    problem.Id  = problemId
+    problem.Url = url
+    return problem, nil
+}
+
+// fetches a problem page reporting download progress to w.Progress
+// fetchers that can't report progress only report completion
+func (w *Workspace) fetchProblem(problemId, url string) (*html.Node, error) {
+    if w.Progress == nil {
+        return w.Fetcher.Fetch(url)
+    }
+    if f, ok := w.Fetcher.(ProgressFetcher); ok {
+        return f.FetchProgress(url, func(read, total int64) {
+            w.Progress(problemId, read, total)
+        })
+    }
+    w.Progress(problemId, 0, -1)
+    doc, err := w.Fetcher.Fetch(url)
+    if err == nil {
+        w.Progress(problemId, 1, 1)
+    }
+    return doc, err
+}
+
+// scrapes sample tests from given contest and problem
+func (w *Workspace) ScrapeTests(src Source, problemId string) ([]Test, error) {
+    problem, err := w.ScrapeProblem(src, problemId)
+    if err != nil {
+        return nil, err
+    }
+    return problem.Tests, nil
+}
+
+// writes the tests and starter solutions of contest to Root/{contestDir} and
+// replaces the current session with one for the contest
+func (w *Workspace) Load(contest Contest) (Session, error) {
+    // local directory to store parsed test cases
+    //TODO: if duplicate dir, update w/ modifier, i.e. 1130 A -> 1130_0 A
+    contestDir, err := filepath.Abs(filepath.Join(w.Root, contest.dir()))
+    if err != nil {
+        return Session{}, err
+    }
+    session := Session{Path: contestDir, Source: contest.source()}
+
+    // fill in missing problem urls
+    for i, problem := range contest.Problems {
+        if problem.Url == "" {
+            judge, err := w.Judge(contest.source().Judge)
+            if err != nil {
+                return Session{}, err
+            }
+            contest.Problems[i].Url = judge.ProblemUrl(contest.source(), problem.Id)
+        }
+    }
+
+    // For each problem write tests to dir /contestId/tests/problemId/
+    for _, problem := range contest.Problems {
+        if err := WriteTests(session.TestDir(problem.Id), problem.Tests); err != nil {
+            return Session{}, err
+        }
+    }
+
+    registry, err := w.ReadTemplates()
+    if err != nil {
+        return Session{}, err
+    }
+    // get starter template
+    t, ok := registry.GetStarter()
+    if !ok {
+        return Session{}, fmt.Errorf("couldn't find starter template in templates list")
+    }
+    // generate solution from starter template for each problem
+    // write to path like contest/A.cpp)
+    cursors := make([]int, len(contest.Problems))
+    for i, problem := range contest.Problems {
+        s, cursor, err := renderSolution(t, solutionData(contest, problem, w.Author))
+        cursors[i] = cursor
+        if err != nil {
+            return Session{}, err
+        }
+        p := filepath.Join(contestDir, fmt.Sprintf("%s%s", problem.Id, t.Ext))
+        if err := os.WriteFile(p, s, 0755); err != nil {
+            return Session{}, err
+        }
+    }
+    // write statements next to the solutions (contest/A.md)
+    for _, problem := range contest.Problems {
+        if problem.Statement.empty() {
+            continue
+        }
+        p := filepath.Join(contestDir, problem.Id+".md")
+        if err := os.WriteFile(p, renderStatement(problem), 0644); err != nil {
+            return Session{}, err
+        }
+    }
+
+    // update session with problem templates and initialized verdicts
+    for i, problem := range contest.Problems {
+        session.Problems = append(session.Problems, ProblemState{
+            FileName:    problem.Id + t.Ext,
+            Name:        problem.Name,
+            Url:         problem.Url,
+            Template:    registry.Starter,
+            Tests:       TestVerdict{Passed: 0, Total: len(problem.Tests)},
+            Submission:  SubmitVerdict{},
+            Interactive: problem.Interactive,
+            TimeLimit:   problem.TimeLimit,
+            MemoryLimit: problem.MemoryLimit,
+            Cursor:      cursors[i],
+        })
+    }
+    if err := w.WriteSession(session); err != nil {
+        return Session{}, err
+    }
+    return session, nil
+}
+
+// switches problem p of session s to template t, generating a solution
+// from t unless one with t's extension already exists. Returns the updated
+// problem state, the caller writes the session.
+func (w *Workspace) UseTemplate(s *Session, p ProblemState, t Template) (ProblemState, error) {
+    fileName := p.Id() + t.Ext
+    path := filepath.Join(s.Path, fileName)
+    p.Cursor = 0
+    if _, err := os.Stat(path); os.IsNotExist(err) {
+        contest := Contest{Id: s.Source.Contest, Source: s.Source}
+        problem := Problem{
+            Id:          p.Id(),
+            Name:        p.Name,
+            Url:         p.Url,
+            Interactive: p.Interactive,
+            TimeLimit:   p.TimeLimit,
+            MemoryLimit: p.MemoryLimit,
+        }
+        sol, cursor, err := renderSolution(t, solutionData(contest, problem, w.Author))
+        if err != nil {
+            return ProblemState{}, err
+        }
+        if err := os.WriteFile(path, sol, 0755); err != nil {
+            return ProblemState{}, err
+        }
+        p.Cursor = cursor
+    } else if err != nil {
+        return ProblemState{}, err
+    }
+
+    old := p.FileName
+    p.FileName = fileName
+    p.Template = t.Name
+    // SetProblem matches on the file name
+    for i := range s.Problems {
+        if s.Problems[i].FileName == old {
+            s.Problems[i] = p
+        }
+    }
+    return p, nil
+}
+
+// path to session.json in the app dir
+func (w *Workspace) SessionPath() string {
+    return filepath.Join(w.AppDir, "session.json")
+}
+
+// path to templates.json in the app dir
+func (w *Workspace) TemplatesPath() string {
+    return filepath.Join(w.AppDir, "templates.json")
+}
+
+// reads the current session from session.json
+func (w *Workspace) ReadSession() (Session, error) {
+    var s Session
+    if err := readJSON(w.SessionPath(), &s); err != nil {
+        return Session{}, err
+    }
+    return s, nil
+}
+
+// replaces session.json with s, creating the app dir if needed
+func (w *Workspace) WriteSession(s Session) error {
+    if err := os.MkdirAll(w.AppDir, 0700); err != nil {
+        return err
+    }
+    return writeJSON(w.SessionPath(), &s)
+}
+
+// read and deserialize TemplateRegistry or create if it doesn't exist
+func (w *Workspace) ReadTemplates() (TemplateRegistry, error) {
+    // build app directory if it doesn't exist
+    if err := os.MkdirAll(w.AppDir, 0700); err != nil {
+        return TemplateRegistry{}, err
+    }
+    p := w.TemplatesPath()
+    registry, err := ReadTemplateRegistry(p)
+    if os.IsNotExist(err) {
+        return InitTemplateRegistry(p)
+    }
+    return registry, err
+}
+
+// validates r and replaces templates.json with it
+func (w *Workspace) WriteTemplates(r TemplateRegistry) error {
+    if err := os.MkdirAll(w.AppDir, 0700); err != nil {
+        return err
+    }
+    return WriteTemplateRegistry(w.TemplatesPath(), r)
+}
+
+// replaces templates.json with the default registry
+func (w *Workspace) InitTemplates() (TemplateRegistry, error) {
+    if err := os.MkdirAll(w.AppDir, 0700); err != nil {
+        return TemplateRegistry{}, err
+    }
+    return InitTemplateRegistry(w.TemplatesPath())
+}
+
+// read and unmarshal json at path to value pointed to by v
+// returns InvalidUnmarshalError if v is nil or not a pointer
+func readJSON(path string, v any) error {
+    // read
+    b, err := os.ReadFile(path)
+    if err != nil {
+        return err
+    }
+    // unmarshal
+    if err := json.Unmarshal(b, v); err != nil {
+        return err
+    }
+    return nil
+}
+
+// marshal value v and write the json to path
+func writeJSON(path string, v any) error {
+    dat, err := json.Marshal(v)
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(path, dat, 0644)
+}
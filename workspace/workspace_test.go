@@ -0,0 +1,142 @@
+package workspace
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "golang.org/x/net/html"
+)
+
+// contest 1336 cut down to problems A and C
+const smallContestPage = `<html><body><table class="problems">
+<tr><th>#</th><th>Name</th></tr>
+<tr><td class="id"><a href="/contest/1336/problem/A">A</a></td><td><div><a href="/contest/1336/problem/A">Linova and Kingdom</a></div></td></tr>
+<tr><td class="id"><a href="/contest/1336/problem/C">C</a></td><td><div><a href="/contest/1336/problem/C">Kaavi and Magic Spell</a></div></td></tr>
+</table></body></html>`
+
+// workspace in temp dirs serving the small contest from testdata, counting
+// the pages fetched by url
+func testWorkspace(t *testing.T) (*Workspace, map[string]int) {
+    fetched := make(map[string]int)
+    f := FetcherFunc(func(url string) (*html.Node, error) {
+        fetched[url]++
+        switch url {
+        case DefaultBaseUrl + "/contest/1336":
+            return html.Parse(strings.NewReader(smallContestPage))
+        case DefaultBaseUrl + "/contest/1336/problem/A":
+            return readFixture(t, filepath.Join("testdata", "problem", "1336A.html")), nil
+        case DefaultBaseUrl + "/contest/1336/problem/C":
+            return readFixture(t, filepath.Join("testdata", "problem", "1336C.html")), nil
+        }
+        return nil, fmt.Errorf("no page at %s", url)
+    })
+    w := &Workspace{Root: t.TempDir(), AppDir: t.TempDir(), Fetcher: f, Workers: 1, Author: "tester"}
+    return w, fetched
+}
+
+func readFile(t *testing.T, path string) string {
+    t.Helper()
+    b, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return string(b)
+}
+
+func TestTrain(t *testing.T) {
+    w, fetched := testWorkspace(t)
+    src := Source{Judge: CodeforcesJudge, Kind: ContestSource, Contest: "1336"}
+    session, err := w.Train(src, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(fetched) != 3 {
+        t.Errorf("fetched %v, want the contest page and both problems", fetched)
+    }
+
+    dir := filepath.Join(w.Root, "1336")
+    if abs, _ := filepath.Abs(dir); session.Path != abs || session.Source != src {
+        t.Errorf("session of %s %+v, want %s", session.Path, session.Source, abs)
+    }
+    wantTests := map[string]int{"A": 3, "C": 4}
+    if len(session.Problems) != 2 {
+        t.Fatalf("session has %d problems, want 2", len(session.Problems))
+    }
+    for _, p := range session.Problems {
+        want, ok := wantTests[p.Id()]
+        if !ok || p.FileName != p.Id()+".cpp" || p.Template != "default" || p.Tests.Total != want {
+            t.Errorf("problem state %+v", p)
+        }
+        tests, err := ReadTests(session.TestDir(p.Id()))
+        if err != nil || len(tests) != want {
+            t.Errorf("problem %s: %d tests (%v), want %d", p.Id(), len(tests), err, want)
+        }
+        if sol := readFile(t, filepath.Join(dir, p.FileName)); !strings.Contains(sol, "tester") {
+            t.Errorf("problem %s: solution lacks the author:\n%s", p.Id(), sol)
+        }
+    }
+    if p, _ := session.ProblemById("C"); p.Name != "C. Kaavi and Magic Spell" || p.MemoryLimit != 512<<20 || p.Url != DefaultBaseUrl+"/contest/1336/problem/C" {
+        t.Errorf("problem C %+v", p)
+    }
+    tests, _ := ReadTests(session.TestDir("A"))
+    if tests[0].Input != "7 4\n1 2\n1 3\n1 4\n3 5\n3 6\n4 7\n" || tests[0].Output != "7\n" {
+        t.Errorf("first test of A %+v", tests[0])
+    }
+    for id, name := range map[string]string{"A": "A. Linova and Kingdom", "C": "C. Kaavi and Magic Spell"} {
+        if statement := readFile(t, filepath.Join(dir, id+".md")); !strings.HasPrefix(statement, "# "+name+"\n") {
+            t.Errorf("%s.md isn't the statement of %s:\n%s", id, name, statement)
+        }
+    }
+
+    saved, err := w.ReadSession()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(saved.Problems) != 2 || saved.Path != session.Path {
+        t.Errorf("session.json holds %+v", saved)
+    }
+}
+
+func TestRetrainSubset(t *testing.T) {
+    w, fetched := testWorkspace(t)
+    src := Source{Judge: CodeforcesJudge, Kind: ContestSource, Contest: "1336"}
+    if _, err := w.Train(src, nil); err != nil {
+        t.Fatal(err)
+    }
+    dir := filepath.Join(w.Root, "1336")
+    solA := filepath.Join(dir, "A.cpp")
+    if err := os.WriteFile(solA, []byte("// my solution\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    statementA := filepath.Join(dir, "A.md")
+    if err := os.WriteFile(statementA, []byte("# my notes\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    // problems given: no contest page, only those problems scraped and written
+    session, err := w.Train(src, []string{"C"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if fetched[DefaultBaseUrl+"/contest/1336"] != 1 || fetched[DefaultBaseUrl+"/contest/1336/problem/A"] != 1 {
+        t.Errorf("fetched %v, want only problem C again", fetched)
+    }
+    if len(session.Problems) != 1 || session.Problems[0].Id() != "C" {
+        t.Errorf("session problems %+v, want only C", session.Problems)
+    }
+    if sol := readFile(t, solA); sol != "// my solution\n" {
+        t.Errorf("retraining C rewrote A.cpp: %q", sol)
+    }
+    if statement := readFile(t, statementA); statement != "# my notes\n" {
+        t.Errorf("retraining C rewrote A.md: %q", statement)
+    }
+    if statement := readFile(t, filepath.Join(dir, "C.md")); !strings.HasPrefix(statement, "# C. Kaavi and Magic Spell\n") {
+        t.Errorf("C.md after retraining:\n%s", statement)
+    }
+    if tests, err := ReadTests(session.TestDir("A")); err != nil || len(tests) != 3 {
+        t.Errorf("retraining C changed the tests of A: %d tests, %v", len(tests), err)
+    }
+}